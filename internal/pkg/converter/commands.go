@@ -0,0 +1,163 @@
+package converter
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Command of the CLI, with its own parameters.
+//
+// Without command, all parameters are available, to keep the flat invocation working.
+type Command struct {
+	Name        string
+	Args        string
+	Description string
+	// Sections or parameters accepted by the command
+	Params []string
+}
+
+var Commands = []Command{
+	{
+		Name:        "convert",
+		Description: "Convert a comic into EPUB",
+		Params:      []string{"Output", "Config", "Shortcut", "Compatibility", "workers", "dry", "dry-verbose", "dry-sample", "preview", "quiet", "json", "events"},
+	},
+	{
+		Name:        "inspect",
+		Description: "Analyze the input and suggest options, without converting",
+		Params:      []string{"input", "Config", "workers", "json"},
+	},
+	{
+		Name:        "profiles",
+		Description: "List the available profiles, including the custom profiles of the config",
+		Params:      []string{"json"},
+	},
+	{
+		Name:        "config",
+		Args:        "[show | get [KEY] | set KEY VALUE | save | reset | validate]",
+		Description: "Show or change your default parameters. Keys are the path in the config file, like image.quality",
+		Params:      []string{"input", "Config", "Shortcut", "Compatibility", "json"},
+	},
+	{
+		Name:        "verify",
+		Args:        "EPUB...",
+		Description: "Verify the structure of EPUB files",
+		Params:      []string{"json"},
+	},
+	{
+		Name:        "serve",
+		Args:        "DIRECTORY",
+		Description: "Serve a directory of EPUB as an OPDS catalog (OPDS 1.2 on /opds, OPDS 2.0 on /opds/v2)",
+		Params:      []string{"opds-addr"},
+	},
+	{
+		Name:        "completion",
+		Args:        "bash|zsh|fish",
+		Description: "Generate the completion script for your shell",
+	},
+	{
+		Name:        "version",
+		Description: "Show current and available version",
+	},
+}
+
+// LookupCommand find a command by name
+func LookupCommand(name string) *Command {
+	for i := range Commands {
+		if Commands[i].Name == name {
+			return &Commands[i]
+		}
+	}
+	return nil
+}
+
+// accept check if the parameter is part of the command
+func (c *Converter) accept(name string) bool {
+	if c.Command == nil || name == "help" {
+		return true
+	}
+	return slices.Contains(c.Command.Params, name) || slices.Contains(c.Command.Params, c.section)
+}
+
+// settings are the scalar values that can be get and set from the command line
+func isSetting(path string, v reflect.Value) bool {
+	if path == "version" {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Int, reflect.Float64, reflect.Bool:
+		return true
+	}
+	return false
+}
+
+// config key to yaml path, the epuboptions prefix is optional
+func (o *Options) keyPath(key string) (path string, ok bool) {
+	o.fields(func(k string, v reflect.Value) {
+		if isSetting(k, v) && (k == key || k == "epuboptions."+key) {
+			path, ok = k, true
+		}
+	})
+	return
+}
+
+// Get the value of a config key
+func (o *Options) Get(key string) (string, error) {
+	path, ok := o.keyPath(key)
+	if !ok {
+		return "", fmt.Errorf("unknown key %q", key)
+	}
+	return o.Values()[path], nil
+}
+
+// Keys all config keys, without the epuboptions prefix
+func (o *Options) Keys() []string {
+	keys := make([]string, 0)
+	o.fields(func(k string, v reflect.Value) {
+		if isSetting(k, v) {
+			keys = append(keys, strings.TrimPrefix(k, "epuboptions."))
+		}
+	})
+	slices.Sort(keys)
+	return keys
+}
+
+// Set the value of a config key
+func (o *Options) Set(key string, value string) (err error) {
+	path, ok := o.keyPath(key)
+	if !ok {
+		return fmt.Errorf("unknown key %q", key)
+	}
+	o.fields(func(k string, v reflect.Value) {
+		if k != path {
+			return
+		}
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(value)
+		case reflect.Int:
+			var i int64
+			if i, err = strconv.ParseInt(value, 10, 64); err == nil {
+				v.SetInt(i)
+			}
+		case reflect.Float64:
+			var f float64
+			if f, err = strconv.ParseFloat(value, 64); err == nil {
+				v.SetFloat(f)
+			}
+		case reflect.Bool:
+			var b bool
+			if b, err = strconv.ParseBool(value); err == nil {
+				v.SetBool(b)
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %q: %w", value, key, err)
+	}
+	o.SetOrigin("config set", path)
+	return nil
+}
@@ -0,0 +1,343 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const completionProgram = "go-comic-converter"
+
+// extensions of the supported inputs, directories are also supported
+var inputExtensions = []string{"cbz", "zip", "cbr", "rar", "pdf"}
+
+// kind of completion of a parameter value or a positional argument
+const (
+	completeNone = iota
+	completeValues
+	completeInput
+	completeFile
+	completeDir
+	completeEPUB
+)
+
+type completion struct {
+	kind   int
+	values []string
+}
+
+type completionFlag struct {
+	name        string
+	description string
+	isBool      bool
+	completion
+}
+
+type completionCommand struct {
+	name        string
+	description string
+	flags       []completionFlag
+	// positional arguments
+	args completion
+}
+
+// complete values of a parameter
+func (c *Converter) completeParam(name string) completion {
+	switch name {
+	case "input":
+		return completion{kind: completeInput}
+	case "output":
+		return completion{kind: completeFile}
+	case "opds-serve":
+		return completion{kind: completeDir}
+	case "profile":
+		codes := make([]string, 0)
+		for _, p := range c.Options.Profiles() {
+			codes = append(codes, p.Code)
+		}
+		return completion{completeValues, codes}
+	case "preset":
+		return completion{completeValues, c.Options.AvailablePresets()}
+	case "format":
+		return completion{completeValues, []string{"jpeg", "png", "copy"}}
+	case "crop-color":
+		return completion{completeValues, []string{"auto", "FFF", "000"}}
+	case "overwrite":
+		return completion{completeValues, []string{"always", "never", "ifnewer"}}
+	case "events":
+		return completion{kind: completeFile}
+	}
+	return completion{}
+}
+
+// complete positional arguments of the command
+func (c *Converter) completeArgs() completion {
+	if c.Command == nil {
+		return completion{}
+	}
+	switch c.Command.Name {
+	case "config":
+		return completion{completeValues, append([]string{"show", "get", "set", "save", "reset", "validate"}, c.Options.Keys()...)}
+	case "verify":
+		return completion{kind: completeEPUB}
+	case "serve":
+		return completion{kind: completeDir}
+	case "completion":
+		return completion{completeValues, []string{"bash", "zsh", "fish"}}
+	}
+	return completion{}
+}
+
+// flags of the command, from the parameters declared in InitParse
+func (c *Converter) completionCommand() completionCommand {
+	cc := completionCommand{args: c.completeArgs()}
+	if c.Command != nil {
+		cc.name, cc.description = c.Command.Name, c.Command.Description
+	}
+	for _, o := range c.order {
+		v, ok := o.(orderName)
+		if !ok {
+			continue
+		}
+		f := c.Cmd.Lookup(v.Value())
+		b, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		cc.flags = append(cc.flags, completionFlag{
+			name:        f.Name,
+			description: strings.TrimSuffix(strings.TrimSpace(strings.SplitN(f.Usage, "\n", 2)[0]), ":"),
+			isBool:      isBool && b.IsBoolFlag(),
+			completion:  c.completeParam(f.Name),
+		})
+	}
+	return cc
+}
+
+// Completion generate the completion script of the shell: bash, zsh or fish
+func Completion(shell string) (string, error) {
+	commands := make([]completionCommand, 0, len(Commands)+1)
+	for _, command := range append([]*Command{nil}, commandRefs()...) {
+		conv := NewCommand(command, nil)
+		if err := conv.Options.LoadConfig(); err != nil {
+			return "", err
+		}
+		conv.InitParse()
+		commands = append(commands, conv.completionCommand())
+	}
+
+	switch shell {
+	case "bash":
+		return bashCompletion(commands), nil
+	case "zsh":
+		return zshCompletion(commands), nil
+	case "fish":
+		return fishCompletion(commands), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q, use bash, zsh or fish", shell)
+}
+
+func commandRefs() []*Command {
+	refs := make([]*Command, len(Commands))
+	for i := range Commands {
+		refs[i] = &Commands[i]
+	}
+	return refs
+}
+
+func bashReply(c completion) string {
+	switch c.kind {
+	case completeValues:
+		return `COMPREPLY=($(compgen -W "` + strings.Join(c.values, " ") + `" -- "$cur"))`
+	case completeInput:
+		return `compopt -o filenames 2>/dev/null; mapfile -t COMPREPLY < <(compgen -d -- "$cur"; compgen -f -- "$cur" | grep -iE '\.(` + strings.Join(inputExtensions, "|") + `)$')`
+	case completeFile:
+		return `compopt -o filenames 2>/dev/null; mapfile -t COMPREPLY < <(compgen -f -- "$cur")`
+	case completeDir:
+		return `compopt -o filenames 2>/dev/null; mapfile -t COMPREPLY < <(compgen -d -- "$cur")`
+	case completeEPUB:
+		return `compopt -o filenames 2>/dev/null; mapfile -t COMPREPLY < <(compgen -d -- "$cur"; compgen -f -- "$cur" | grep -iE '\.epub$')`
+	}
+	return `COMPREPLY=()`
+}
+
+func bashCompletion(commands []completionCommand) string {
+	var b strings.Builder
+	fn := "_" + strings.ReplaceAll(completionProgram, "-", "_")
+	names := make([]string, 0)
+	for _, cc := range commands[1:] {
+		names = append(names, cc.name)
+	}
+
+	b.WriteString("# bash completion for " + completionProgram + "\n")
+	b.WriteString(fn + "() {\n")
+	b.WriteString("    local cur prev cmd=\"\"\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    if [[ ${COMP_CWORD} -gt 1 ]]; then\n")
+	b.WriteString("        case \"${COMP_WORDS[1]}\" in\n")
+	b.WriteString("        " + strings.Join(names, "|") + ") cmd=\"${COMP_WORDS[1]}\" ;;\n")
+	b.WriteString("        esac\n")
+	b.WriteString("    fi\n\n")
+
+	// values of the parameters, they are the same for all commands
+	b.WriteString("    case \"${prev#-}\" in\n")
+	seen := map[string]bool{}
+	for _, cc := range commands {
+		for _, f := range cc.flags {
+			if f.isBool || seen[f.name] {
+				continue
+			}
+			seen[f.name] = true
+			b.WriteString("    " + f.name + "|-" + f.name + ")\n        " + bashReply(f.completion) + "\n        return ;;\n")
+		}
+	}
+	b.WriteString("    esac\n\n")
+
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	b.WriteString("        case \"$cmd\" in\n")
+	for _, cc := range commands {
+		flags := make([]string, 0, len(cc.flags))
+		for _, f := range cc.flags {
+			flags = append(flags, "-"+f.name)
+		}
+		b.WriteString("        \"" + cc.name + "\") COMPREPLY=($(compgen -W \"" + strings.Join(flags, " ") + "\" -- \"$cur\")) ;;\n")
+	}
+	b.WriteString("        esac\n")
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n\n")
+
+	b.WriteString("    case \"$cmd\" in\n")
+	b.WriteString("    \"\")\n")
+	b.WriteString("        if [[ ${COMP_CWORD} -eq 1 ]]; then\n")
+	b.WriteString("            COMPREPLY=($(compgen -W \"" + strings.Join(names, " ") + "\" -- \"$cur\"))\n")
+	b.WriteString("        fi ;;\n")
+	for _, cc := range commands[1:] {
+		if cc.args.kind != completeNone {
+			b.WriteString("    " + cc.name + ")\n        " + bashReply(cc.args) + " ;;\n")
+		}
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -F " + fn + " " + completionProgram + "\n")
+	return b.String()
+}
+
+// escape description for zsh _arguments spec
+func zshEscape(s string) string {
+	return strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:").Replace(s)
+}
+
+func zshAction(c completion) string {
+	switch c.kind {
+	case completeValues:
+		return "(" + strings.Join(c.values, " ") + ")"
+	case completeInput:
+		return `_files -g "*.(#i)(` + strings.Join(inputExtensions, "|") + `)(-.)"`
+	case completeFile:
+		return "_files"
+	case completeDir:
+		return "_files -/"
+	case completeEPUB:
+		return `_files -g "*.(#i)epub(-.)"`
+	}
+	return " "
+}
+
+func zshCompletion(commands []completionCommand) string {
+	var b strings.Builder
+	fn := "_" + strings.ReplaceAll(completionProgram, "-", "_")
+
+	b.WriteString("#compdef " + completionProgram + "\n\n")
+	b.WriteString(fn + "() {\n")
+	b.WriteString("    local -a commands\n")
+	b.WriteString("    commands=(\n")
+	for _, cc := range commands[1:] {
+		b.WriteString("        '" + cc.name + ":" + zshEscape(cc.description) + "'\n")
+	}
+	b.WriteString("    )\n\n")
+
+	b.WriteString("    local cmd=\"\"\n")
+	b.WriteString("    if (( CURRENT > 2 )) && (( ${commands[(I)${words[2]}:*]} )); then\n")
+	b.WriteString("        cmd=\"${words[2]}\"\n")
+	b.WriteString("        shift words\n")
+	b.WriteString("        (( CURRENT-- ))\n")
+	b.WriteString("    fi\n\n")
+
+	b.WriteString("    case \"$cmd\" in\n")
+	for _, cc := range commands {
+		b.WriteString("    \"" + cc.name + "\")\n")
+		b.WriteString("        _arguments -s \\\n")
+		for _, f := range cc.flags {
+			spec := "-" + f.name + "[" + zshEscape(f.description) + "]"
+			if !f.isBool {
+				spec += ":" + f.name + ":" + zshEscape(zshAction(f.completion))
+			}
+			b.WriteString("            '" + spec + "' \\\n")
+		}
+		switch {
+		case cc.name == "":
+			b.WriteString("            '1: :{_describe command commands}'\n")
+		case cc.args.kind == completeEPUB:
+			b.WriteString("            '*:epub:" + zshEscape(zshAction(cc.args)) + "'\n")
+		case cc.args.kind != completeNone:
+			b.WriteString("            '1:" + cc.name + ":" + zshEscape(zshAction(cc.args)) + "'\n")
+		default:
+			b.WriteString("            && return\n")
+		}
+		b.WriteString("        ;;\n")
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	b.WriteString(fn + " \"$@\"\n")
+	return b.String()
+}
+
+func fishEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(s)
+}
+
+func fishArgs(c completion) string {
+	switch c.kind {
+	case completeValues:
+		return " -x -a '" + strings.Join(c.values, " ") + "'"
+	case completeInput:
+		return " -x -a '(__fish_complete_suffix ." + strings.Join(inputExtensions, " .") + ")'"
+	case completeFile:
+		return " -r -F"
+	case completeDir:
+		return " -x -a '(__fish_complete_directories)'"
+	case completeEPUB:
+		return " -x -a '(__fish_complete_suffix .epub)'"
+	}
+	return " -x"
+}
+
+func fishCompletion(commands []completionCommand) string {
+	var b strings.Builder
+	names := make([]string, 0)
+	for _, cc := range commands[1:] {
+		names = append(names, cc.name)
+	}
+	p := "complete -c " + completionProgram
+
+	b.WriteString("# fish completion for " + completionProgram + "\n")
+	b.WriteString(p + " -f\n")
+	for _, cc := range commands[1:] {
+		b.WriteString(p + " -n '__fish_use_subcommand' -a " + cc.name + " -d '" + fishEscape(cc.description) + "'\n")
+	}
+
+	for _, cc := range commands {
+		cond := "__fish_seen_subcommand_from " + cc.name
+		if cc.name == "" {
+			cond = "not __fish_seen_subcommand_from " + strings.Join(names, " ")
+		}
+		for _, f := range cc.flags {
+			line := p + " -n '" + cond + "' -o " + f.name + " -d '" + fishEscape(f.description) + "'"
+			if !f.isBool {
+				line += fishArgs(f.completion)
+			}
+			b.WriteString(line + "\n")
+		}
+		if cc.name != "" && cc.args.kind != completeNone {
+			b.WriteString(p + " -n '" + cond + "'" + fishArgs(cc.args) + "\n")
+		}
+	}
+	return b.String()
+}
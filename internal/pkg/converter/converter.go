@@ -6,7 +6,6 @@
 package converter
 
 import (
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -16,28 +15,58 @@ import (
 	"regexp"
 	"runtime"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubevent"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubinspect"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubname"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
+	"github.com/celogeek/go-comic-converter/v3/pkg/epuboptions"
 )
 
 type Converter struct {
 	Options *Options
 	Cmd     *flag.FlagSet
-
+	// Command selected, nil for the flat invocation
+	Command *Command
+
+	args            []string
+	positional      []string
+	configErr       error
+	section         string
+	sectionAdded    bool
 	order           []order
 	isZeroValueErrs []error
 	startAt         time.Time
 }
 
-// New Create a new parser
+// New Create a new parser, the command is taken from the first argument
 func New() *Converter {
+	args := os.Args[1:]
+	var command *Command
+	if len(args) > 0 {
+		if command = LookupCommand(args[0]); command != nil {
+			args = args[1:]
+		}
+	}
+	return NewCommand(command, args)
+}
+
+// NewCommand Create a new parser for the command, nil for the flat invocation
+func NewCommand(command *Command, args []string) *Converter {
 	o := NewOptions()
-	cmd := flag.NewFlagSet("go-comic-converter", flag.ExitOnError)
+	name := filepath.Base(os.Args[0])
+	if command != nil {
+		name += " " + command.Name
+	}
+	cmd := flag.NewFlagSet(name, flag.ExitOnError)
 	conv := &Converter{
 		Options: o,
 		Cmd:     cmd,
+		Command: command,
+		args:    args,
 		order:   make([]order, 0),
 		startAt: time.Now(),
 	}
@@ -45,7 +74,11 @@ func New() *Converter {
 	var cmdOutput strings.Builder
 	cmd.SetOutput(&cmdOutput)
 	cmd.Usage = func() {
-		utils.Printf("Usage of %s:\n", filepath.Base(os.Args[0]))
+		if command != nil {
+			utils.Printf("Usage of %s: %s\n\n%s\n", name, strings.TrimSpace("[options] "+command.Args), command.Description)
+		} else {
+			utils.Printf("Usage of %s: [command] [options]\n", name)
+		}
 		for _, o := range conv.order {
 			switch v := o.(type) {
 			case orderSection:
@@ -54,6 +87,12 @@ func New() *Converter {
 				utils.Println(conv.Usage(v.isString, cmd.Lookup(v.Value())))
 			}
 		}
+		if command == nil {
+			utils.Printf("\nCommands:\n")
+			for _, c := range Commands {
+				utils.Printf("  %-10s %s\n", c.Name, c.Description)
+			}
+		}
 		if cmdOutput.Len() > 0 {
 			utils.Printf("\nError: %s", cmdOutput.String())
 		}
@@ -64,47 +103,181 @@ func New() *Converter {
 
 // LoadConfig Load default options (config + default)
 func (c *Converter) LoadConfig() error {
-	return c.Options.LoadConfig()
+	// the config file and the preset are needed before parsing to use their values as default
+	c.Options.ConfigFile = lookupArg(c.args, "config", EnvPrefix+"CONFIG")
+	c.Options.Preset = lookupArg(c.args, "preset", EnvPrefix+"PRESET")
+	if err := c.Options.LoadConfig(); err != nil {
+		// the config command can validate or reset an invalid config
+		if c.Command == nil || c.Command.Name != "config" {
+			return err
+		}
+		c.configErr = err
+	}
+	return nil
+}
+
+// split joined errors
+func splitErrors(err error) []error {
+	if e, ok := err.(interface{ Unwrap() []error }); ok {
+		return e.Unwrap()
+	}
+	return []error{err}
+}
+
+// ConfigError error of the config file, kept by the config command
+func (c *Converter) ConfigError() error {
+	return c.configErr
+}
+
+// ValidateConfigFile Check the config file: its keys, then the settings of the default config and of each preset.
+func (c *Converter) ValidateConfigFile() []error {
+	if c.configErr != nil {
+		return splitErrors(c.configErr)
+	}
+	errs := make([]error, 0)
+	base := c.Options.newBase()
+	if err := base.LoadConfig(); err != nil {
+		return splitErrors(err)
+	}
+	if err := (&Converter{Options: base}).ValidateConfig(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, name := range base.AvailablePresets() {
+		preset := c.Options.newBase()
+		preset.Preset = name
+		err := preset.LoadConfig()
+		if err == nil {
+			err = (&Converter{Options: preset}).ValidateConfig()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("preset %q: %w", name, err))
+		}
+	}
+	return errs
+}
+
+// lookupArg find the value of a string parameter before parsing, or fallback to the environment variable
+func lookupArg(args []string, name string, env string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			return v
+		}
+	}
+	return os.Getenv(env)
 }
 
 // AddSection Create a new section of config
+//
+// The section is displayed only if the command accept one of its parameters.
 func (c *Converter) AddSection(section string) {
-	c.order = append(c.order, orderSection{value: section})
+	c.section = section
+	c.sectionAdded = false
+}
+
+// add a parameter to the usage, after its section
+func (c *Converter) addOrder(o orderName) {
+	if !c.sectionAdded {
+		c.order = append(c.order, orderSection{value: c.section})
+		c.sectionAdded = true
+	}
+	c.order = append(c.order, o)
 }
 
 // AddStringParam Add a string parameter
 func (c *Converter) AddStringParam(p *string, name string, value string, usage string) {
+	if !c.accept(name) {
+		*p = value
+		return
+	}
 	c.Cmd.StringVar(p, name, value, usage)
-	c.order = append(c.order, orderName{value: name, isString: true})
+	c.addOrder(orderName{value: name, isString: true})
 }
 
 // AddIntParam Add an integer parameter
 func (c *Converter) AddIntParam(p *int, name string, value int, usage string) {
+	if !c.accept(name) {
+		*p = value
+		return
+	}
 	c.Cmd.IntVar(p, name, value, usage)
-	c.order = append(c.order, orderName{value: name})
+	c.addOrder(orderName{value: name})
 }
 
 // AddFloatParam Add an float parameter
 func (c *Converter) AddFloatParam(p *float64, name string, value float64, usage string) {
+	if !c.accept(name) {
+		*p = value
+		return
+	}
 	c.Cmd.Float64Var(p, name, value, usage)
-	c.order = append(c.order, orderName{value: name})
+	c.addOrder(orderName{value: name})
 }
 
 // AddBoolParam Add a boolean parameter
 func (c *Converter) AddBoolParam(p *bool, name string, value bool, usage string) {
+	if !c.accept(name) {
+		*p = value
+		return
+	}
 	c.Cmd.BoolVar(p, name, value, usage)
-	c.order = append(c.order, orderName{value: name})
+	c.addOrder(orderName{value: name})
+}
+
+// inputsValue collect the repeated -input, the first one is the Input.
+type inputsValue struct {
+	o *Options
+}
+
+func (v *inputsValue) String() string {
+	if v.o == nil {
+		return ""
+	}
+	return v.o.Input
+}
+
+func (v *inputsValue) Set(s string) error {
+	if v.o.Input == "" {
+		v.o.Input = s
+	}
+	v.o.Inputs = append(v.o.Inputs, epuboptions.Source{Path: s})
+	return nil
+}
+
+// AddInputParam Add the input parameter, it can be repeated to merge several inputs
+func (c *Converter) AddInputParam(name string, usage string) {
+	if !c.accept(name) {
+		return
+	}
+	c.Cmd.Var(&inputsValue{c.Options}, name, usage)
+	c.addOrder(orderName{value: name, isString: true})
 }
 
 // InitParse Initialize the parser with all section and parameter.
 func (c *Converter) InitParse() {
 	c.AddSection("Output")
-	c.AddStringParam(&c.Options.Input, "input", "", "Source of comic to convert: directory, cbz, zip, cbr, rar, pdf")
+	c.AddInputParam("input", "Source of comic to convert: directory, cbz, zip, cbr, rar, pdf. Repeat it to merge several sources into an omnibus.")
 	c.AddStringParam(&c.Options.Output, "output", "", "Output of the EPUB (directory or EPUB): (default [INPUT].epub)")
 	c.AddStringParam(&c.Options.Author, "author", "GO Comic Converter", "Author of the EPUB")
 	c.AddStringParam(&c.Options.Title, "title", "", "Title of the EPUB")
+	c.AddStringParam(&c.Options.OutputTemplate, "output-template", c.Options.OutputTemplate, "Name of the EPUB relative to the output directory, like \"{series}/{series} v{volume:02} ({part}-{parts}).epub\"\nFields: {"+strings.Join(epubname.Fields, "}, {")+"}. Numbers can be padded with zeros: {part:02}")
+	c.AddStringParam(&c.Options.Overwrite, "overwrite", c.Options.Overwrite, "Overwrite an existing EPUB: always, never or ifnewer (only if the input is newer)")
+	c.AddBoolParam(&c.Options.Reproducible, "reproducible", c.Options.Reproducible, "Reproducible build: the same inputs and options give the same EPUB, dated from SOURCE_DATE_EPOCH or the last modification of the inputs")
+	c.AddBoolParam(&c.Options.Force, "force", false, "Convert again even if the input and the options are unchanged since the last conversion")
+	c.AddBoolParam(&c.Options.DeliverToDevice, "deliver-to-device", false, "Copy the EPUB to the mounted e-reader (Kindle, Kobo). The profile of the device is used if none is set.")
 
 	c.AddSection("Config")
+	c.AddStringParam(&c.Options.ConfigFile, "config", c.Options.ConfigFile, "Config file to use instead of ~/.go-comic-converter.yaml (env "+EnvPrefix+"CONFIG)")
+	c.AddStringParam(&c.Options.Preset, "preset", c.Options.Preset, "Preset of the config file to use, work also with show, save and reset (env "+EnvPrefix+"PRESET): "+strings.Join(c.Options.AvailablePresets(), ", "))
 	c.AddStringParam(&c.Options.Profile, "profile", c.Options.Profile, "Profile to use: \n"+c.Options.AvailableProfiles())
 	c.AddIntParam(&c.Options.Image.Quality, "quality", c.Options.Image.Quality, "Quality of the image")
 	c.AddBoolParam(&c.Options.Image.GrayScale, "grayscale", c.Options.Image.GrayScale, "Grayscale image. Ideal for eInk devices.")
@@ -116,6 +289,13 @@ func (c *Converter) InitParse() {
 	c.AddIntParam(&c.Options.Image.Crop.Bottom, "crop-ratio-bottom", c.Options.Image.Crop.Bottom, "Crop ratio bottom: ratio of pixels allow to be non blank while cutting on the bottom.")
 	c.AddIntParam(&c.Options.Image.Crop.Limit, "crop-limit", c.Options.Image.Crop.Limit, "Crop limit: maximum number of cropping in percentage allowed. 0 mean unlimited.")
 	c.AddBoolParam(&c.Options.Image.Crop.SkipIfLimitReached, "crop-skip-if-limit-reached", c.Options.Image.Crop.SkipIfLimitReached, "Crop skip if limit reached.")
+	c.AddStringParam(&c.Options.Image.Crop.Color, "crop-color", c.Options.Image.Crop.Color, "Crop color: color of the margins in hexadecimal format RGB, or auto to detect it on each edge. White=FFF, Black=000. Blank image detection use it too.")
+	c.AddIntParam(&c.Options.Image.Crop.Tolerance, "crop-tolerance", c.Options.Image.Crop.Tolerance, "Crop tolerance: maximum difference of luminance with the crop color, between 0 and 255")
+	c.AddIntParam(&c.Options.Image.Crop.SpeckArea, "crop-speck-area", c.Options.Image.Crop.SpeckArea, "Crop speck area: maximum area of the marks ignored by the crop, like page numbers and dust, in per thousand of the page. 0 = disabled, 1 is a good start.")
+	c.AddIntParam(&c.Options.Image.Crop.SpeckDistance, "crop-speck-distance", c.Options.Image.Crop.SpeckDistance, "Crop speck distance: minimum distance of the marks from the content, in percentage of the page")
+	c.AddBoolParam(&c.Options.Image.Crop.RemoveSpecks, "crop-remove-specks", c.Options.Image.Crop.RemoveSpecks, "Crop remove specks: erase the marks ignored by the crop, even if the crop keeps them")
+	c.AddIntParam(&c.Options.Image.Crop.Mode, "crop-mode", c.Options.Image.Crop.Mode, "Crop mode\n0 = crop each page\n1 = same crop for all pages\n2 = same crop for left pages, and for right pages\nThe cover, double pages and pages too different from the others keep their own crop.")
+	c.AddIntParam(&c.Options.Image.Crop.Percentile, "crop-percentile", c.Options.Image.Crop.Percentile, "Crop percentile: percentile of the margins of the pages used by the crop mode 1 and 2. 50 = median, lower crop less.")
 	c.AddIntParam(&c.Options.Image.Brightness, "brightness", c.Options.Image.Brightness, "Brightness readjustment: between -100 and 100, > 0 lighter, < 0 darker")
 	c.AddIntParam(&c.Options.Image.Contrast, "contrast", c.Options.Image.Contrast, "Contrast readjustment: between -100 and 100, > 0 more contrast, < 0 less contrast")
 	c.AddBoolParam(&c.Options.Image.AutoContrast, "autocontrast", c.Options.Image.AutoContrast, "Improve contrast automatically")
@@ -123,10 +303,17 @@ func (c *Converter) InitParse() {
 	c.AddBoolParam(&c.Options.Image.AutoSplitDoublePage, "autosplitdoublepage", c.Options.Image.AutoSplitDoublePage, "Auto Split double page when width > height")
 	c.AddBoolParam(&c.Options.Image.KeepDoublePageIfSplit, "keepdoublepageifsplit", c.Options.Image.KeepDoublePageIfSplit, "Keep the double page if split")
 	c.AddBoolParam(&c.Options.Image.KeepSplitDoublePageAspect, "keepsplitdoublepageaspect", c.Options.Image.KeepSplitDoublePageAspect, "Keep aspect of split part of a double page (best for landscape rendering)")
+	c.AddBoolParam(&c.Options.Image.SplitDoublePageGutter, "split-double-page-gutter", c.Options.Image.SplitDoublePageGutter, "Split double page at the gutter: lookup for a band without ink near the middle. A double page without gutter is not split.")
+	c.AddIntParam(&c.Options.Image.SplitDoublePageOverlap, "split-double-page-overlap", c.Options.Image.SplitDoublePageOverlap, "Split double page overlap: each part keeps this percentage of the width of the double page from the other part, between 0 and 10")
+	c.AddBoolParam(&c.Options.Image.SplitDoublePageTrimGutter, "split-double-page-trim-gutter", c.Options.Image.SplitDoublePageTrimGutter, "Split double page trim gutter: remove the gutter and its shadow from both parts")
+	c.AddBoolParam(&c.Options.Image.MergeSpread, "merge-spread", c.Options.Image.MergeSpread, "Merge spread: compose the facing pages into one landscape image, for the readers showing one page at a time in landscape. The double pages are not split.")
+	c.AddBoolParam(&c.Options.Image.DetectSpread, "detect-spread", c.Options.Image.DetectSpread, "Detect spread: split only the landscape images that are two pages, and show facing the pages of a spread scanned as two files")
 	c.AddBoolParam(&c.Options.Image.NoBlankImage, "noblankimage", c.Options.Image.NoBlankImage, "Remove blank image")
 	c.AddBoolParam(&c.Options.Image.Manga, "manga", c.Options.Image.Manga, "Manga mode (right to left)")
 	c.AddBoolParam(&c.Options.Image.HasCover, "hascover", c.Options.Image.HasCover, "Has cover. Indicate if your comic have a cover. The first page will be used as a cover and include after the title.")
 	c.AddIntParam(&c.Options.LimitMb, "limitmb", c.Options.LimitMb, "Limit size of the EPUB: Default nolimit (0), Minimum 20")
+	c.AddIntParam(&c.Options.SplitMode, "split-mode", c.Options.SplitMode, "Split mode\n0 = by size, with -limitmb\n1 = one EPUB per top level directory\n2 = by page count, with -split-pages\n3 = by size, with -limitmb, at the nearest chapter")
+	c.AddIntParam(&c.Options.SplitPages, "split-pages", c.Options.SplitPages, "Number of pages of each EPUB with -split-mode 2")
 	c.AddBoolParam(&c.Options.StripFirstDirectoryFromToc, "strip", c.Options.StripFirstDirectoryFromToc, "Strip first directory from the TOC if only 1")
 	c.AddIntParam(&c.Options.SortPathMode, "sort", c.Options.SortPathMode, "Sort path mode\n0 = alpha for path and file\n1 = alphanumeric for path and alpha for file\n2 = alphanumeric for path and file")
 	c.AddStringParam(&c.Options.Image.View.Color.Foreground, "foreground-color", c.Options.Image.View.Color.Foreground, "Foreground color in hexadecimal format RGB. Black=000, White=FFF")
@@ -153,12 +340,20 @@ func (c *Converter) InitParse() {
 	c.AddSection("Compatibility")
 	c.AddBoolParam(&c.Options.Image.AppleBookCompatibility, "applebookcompatibility", c.Options.Image.AppleBookCompatibility, "Apple book compatibility")
 
+	c.AddSection("OPDS")
+	c.AddStringParam(&c.Options.OpdsServe, "opds-serve", "", "Serve a directory of EPUB as an OPDS catalog (OPDS 1.2 on /opds, OPDS 2.0 on /opds/v2)")
+	c.AddStringParam(&c.Options.OpdsAddr, "opds-addr", ":8080", "Address to listen to for the OPDS catalog")
+
 	c.AddSection("Other")
 	c.AddIntParam(&c.Options.Workers, "workers", runtime.NumCPU(), "Number of workers")
 	c.AddBoolParam(&c.Options.Dry, "dry", false, "Dry run to show all options")
 	c.AddBoolParam(&c.Options.DryVerbose, "dry-verbose", false, "Display also sorted files after the TOC")
+	c.AddIntParam(&c.Options.DrySample, "dry-sample", 0, "Percentage of pages processed by the dry run to estimate the size, the parts and the layout\n  0 = no processing, only the TOC\n100 = all pages, without writing the EPUB")
+	c.AddBoolParam(&c.Options.Preview, "preview", false, "Write an HTML report of the processed pages instead of the EPUB: source and result side by side, crop area and spreads. Use -dry-sample to preview only a part of the pages.")
+	c.AddBoolParam(&c.Options.Inspect, "inspect", false, "Analyze the input and suggest options, without converting")
 	c.AddBoolParam(&c.Options.Quiet, "quiet", false, "Disable progress bar")
 	c.AddBoolParam(&c.Options.Json, "json", false, "Output progression and information in Json format")
+	c.AddStringParam(&c.Options.Events, "events", "", "Write the events of the conversion in a NDJSON file, the progress bar stays on the terminal")
 	c.AddBoolParam(&c.Options.Version, "version", false, "Show current and available version")
 	c.AddBoolParam(&c.Options.Help, "help", false, "Show this help message")
 }
@@ -168,6 +363,9 @@ func (c *Converter) Usage(isString bool, f *flag.Flag) string {
 	var b strings.Builder
 	b.WriteString("  -" + f.Name)
 	name, usage := flag.UnquoteUsage(f)
+	if isString && name == "value" {
+		name = "string"
+	}
 	if len(name) > 0 {
 		b.WriteString(" ")
 		b.WriteString(name)
@@ -229,14 +427,35 @@ func (c *Converter) isZeroValue(f *flag.Flag, value string) (ok bool, err error)
 
 // Parse all parameters
 func (c *Converter) Parse() {
-	if err := c.Cmd.Parse(os.Args[1:]); err != nil {
-		utils.Fatalf("cannot parse command line options: %v", err)
+	// options and positional arguments of the commands can be mixed
+	args := c.args
+	for {
+		if err := c.Cmd.Parse(args); err != nil {
+			utils.Fatalf("cannot parse command line options: %v", err)
+		}
+		if c.Command == nil || c.Cmd.NArg() == 0 {
+			break
+		}
+		c.positional = append(c.positional, c.Cmd.Arg(0))
+		args = c.Cmd.Args()[1:]
 	}
 	if c.Options.Help {
 		c.Cmd.Usage()
 		os.Exit(0)
 	}
 
+	if err := c.loadSidecars(); err != nil {
+		utils.Fatalf("cannot load sidecar config: %v", err)
+	}
+	c.Cmd.Visit(func(f *flag.Flag) {
+		if path, ok := c.Options.FieldPath(f.Value); ok {
+			c.Options.SetOrigin("flag -"+f.Name, path)
+		}
+	})
+
+	// values changed by the shortcuts
+	values := c.Options.Values()
+
 	if c.Options.Auto {
 		c.Options.Image.AutoContrast = true
 		c.Options.Image.AutoRotate = true
@@ -282,7 +501,90 @@ func (c *Converter) Parse() {
 
 	if c.Options.Image.View.PortraitOnly {
 		c.Options.Image.KeepSplitDoublePageAspect = false
+		c.Options.Image.MergeSpread = false
 	}
+
+	// preview process the pages without writing the EPUB
+	if c.Options.Preview {
+		c.Options.Dry = true
+		if c.Options.DrySample == 0 {
+			c.Options.DrySample = 100
+		}
+	}
+
+	changed := make([]string, 0)
+	for k, v := range c.Options.Values() {
+		if values[k] != v {
+			changed = append(changed, k)
+		}
+	}
+	c.Options.SetOrigin("shortcut", changed...)
+}
+
+// apply the sidecar config of the input, then the environment variables and the command line again to keep their priority.
+func (c *Converter) loadSidecars() error {
+	if c.Options.Input == "" {
+		return nil
+	}
+	flags := map[string]string{}
+	c.Cmd.Visit(func(f *flag.Flag) {
+		// the inputs are not part of the config
+		if f.Name != "input" {
+			flags[f.Name] = f.Value.String()
+		}
+	})
+	files, err := c.Options.LoadSidecars()
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	if err = c.Options.profiles.Add(c.Options.CustomProfiles); err != nil {
+		return err
+	}
+	if err = c.Options.loadEnv(); err != nil {
+		return err
+	}
+	for name, value := range flags {
+		if err = c.Cmd.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Args positional arguments of the command
+func (c *Converter) Args() []string {
+	if c.Command == nil {
+		return c.Cmd.Args()
+	}
+	return c.positional
+}
+
+// Arg positional argument of the command, empty if missing
+func (c *Converter) Arg(i int) string {
+	if args := c.Args(); i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// IsSet check if the parameter has been set on the command line
+func (c *Converter) IsSet(name string) (found bool) {
+	c.Cmd.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return
+}
+
+// ProfileIsSet check if the profile has been chosen by the user, from the command line or a config.
+func (c *Converter) ProfileIsSet() bool {
+	return c.Options.Origin("profile") != defaultOrigin
+}
+
+// GrayScaleIsSet check if grayscale has been chosen by the user, from the command line or a config.
+func (c *Converter) GrayScaleIsSet() bool {
+	return c.Options.Origin("epuboptions.image.grayscale") != defaultOrigin
 }
 
 // Validate Check parameters
@@ -297,6 +599,11 @@ func (c *Converter) Validate() error {
 		return err
 	}
 
+	// Check omnibus
+	if err = c.validateInputs(); err != nil {
+		return err
+	}
+
 	// Check Output
 	var defaultOutput string
 	inputBase := filepath.Clean(c.Options.Input)
@@ -340,6 +647,117 @@ func (c *Converter) Validate() error {
 		c.Options.Title = filepath.Base(defaultOutput[0 : len(defaultOutput)-len(ext)])
 	}
 
+	// Series
+	c.Options.Series, c.Options.Volume = c.series()
+
+	// Reproducible
+	buildTime, err := c.Options.BuildTime()
+	if err != nil {
+		return err
+	}
+
+	// Output template
+	if c.Options.OutputTemplate != "" {
+		c.Options.OutputFields = c.outputFields(buildTime)
+	}
+
+	return c.ValidateConfig()
+}
+
+// check the inputs of an omnibus and set the title of each source
+func (c *Converter) validateInputs() error {
+	if len(c.Options.Inputs) <= 1 {
+		c.Options.Inputs = nil
+		return nil
+	}
+	if c.Options.Image.Format == "copy" {
+		return errors.New("format copy doesn't support multiple inputs")
+	}
+	titles := map[string]int{}
+	for i, source := range c.Options.Inputs {
+		if _, err := os.Stat(source.Path); err != nil {
+			return err
+		}
+		title := sourceTitle(source.Path)
+		// sources with the same title would be merged in the TOC
+		if titles[title]++; titles[title] > 1 {
+			title = fmt.Sprintf("%s (%d)", title, titles[title])
+		}
+		c.Options.Inputs[i].Title = title
+	}
+	return nil
+}
+
+// values of the output template, the part fields are set for each EPUB
+func (c *Converter) outputFields(buildTime time.Time) map[string]string {
+	source := filepath.Base(filepath.Clean(c.Options.Input))
+	if fi, err := os.Stat(c.Options.Input); err == nil && !fi.IsDir() {
+		source = strings.TrimSuffix(source, filepath.Ext(source))
+	}
+	fields := map[string]string{
+		"title":   c.Options.Title,
+		"series":  c.Options.Series,
+		"author":  c.Options.Author,
+		"profile": c.Options.Profile,
+		"date":    buildTime.Format("2006-01-02"),
+		"source":  source,
+	}
+	if c.Options.Volume > 0 {
+		fields["volume"] = utils.IntToString(c.Options.Volume)
+	}
+	if ci, ok := epubinspect.ReadComicInfo(c.Options.Input); ok {
+		if ci.Volume != "" {
+			fields["volume"] = ci.Volume
+		}
+		fields["number"] = ci.Number
+	}
+	return fields
+}
+
+// volume at the end of a title: "MyManga Vol. 05", "MyManga v05", "MyManga - 05"
+var titleVolume = regexp.MustCompile(`(?i)^(.*?)[\s._-]*(?:(?:vol(?:ume)?|tome|v|t)[\s.]*|#)?(\d+)$`)
+
+// series of the EPUB and its volume, from the ComicInfo.xml, or the title without the volume.
+func (c *Converter) series() (string, int) {
+	if ci, ok := epubinspect.ReadComicInfo(c.Options.Input); ok && ci.Series != "" {
+		volume, err := strconv.Atoi(ci.Volume)
+		if err != nil {
+			volume, _ = strconv.Atoi(ci.Number)
+		}
+		return ci.Series, volume
+	}
+	if m := titleVolume.FindStringSubmatch(c.Options.Title); m != nil && m[1] != "" {
+		volume, _ := strconv.Atoi(m[2])
+		return m[1], volume
+	}
+	return c.Options.Title, 0
+}
+
+// title of a source from its ComicInfo.xml, or its name
+func sourceTitle(path string) string {
+	title := ""
+	if ci, ok := epubinspect.ReadComicInfo(path); ok {
+		switch {
+		case ci.Title != "":
+			title = ci.Title
+		case ci.Series != "" && ci.Volume != "":
+			title = ci.Series + " Vol. " + ci.Volume
+		case ci.Series != "" && ci.Number != "":
+			title = ci.Series + " #" + ci.Number
+		}
+	}
+	if title == "" {
+		base := filepath.Base(filepath.Clean(path))
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			base = strings.TrimSuffix(base, filepath.Ext(base))
+		}
+		title = base
+	}
+	return strings.ReplaceAll(title, string(filepath.Separator), "-")
+}
+
+// ValidateConfig Check the settings, without the input and output
+func (c *Converter) ValidateConfig() error {
 	// Profile
 	if c.Options.Profile == "" {
 		return errors.New("profile missing")
@@ -347,6 +765,15 @@ func (c *Converter) Validate() error {
 
 	if p := c.Options.GetProfile(); p == nil {
 		return fmt.Errorf("profile %q doesn't exists", c.Options.Profile)
+	} else if c.Options.Image.Format != "copy" && !p.SupportFormat(c.Options.Image.Format) {
+		return fmt.Errorf("profile %q doesn't support format %q", c.Options.Profile, c.Options.Image.Format)
+	} else if c.Options.Image.Format != "copy" && !c.Options.Image.GrayScale && !p.SupportColor() {
+		return fmt.Errorf("profile %q doesn't support color, use -grayscale", c.Options.Profile)
+	}
+
+	// Preset
+	if c.Options.Preset != "" && !c.Options.HasPreset() {
+		return fmt.Errorf("preset %q doesn't exists", c.Options.Preset)
 	}
 
 	// LimitMb
@@ -354,6 +781,29 @@ func (c *Converter) Validate() error {
 		return errors.New("limitmb should be 0 or >= 20")
 	}
 
+	// Overwrite
+	if !slices.Contains([]string{"always", "never", "ifnewer"}, c.Options.Overwrite) {
+		return errors.New("overwrite should be always, never or ifnewer")
+	}
+
+	// Output template
+	if c.Options.OutputTemplate != "" {
+		if err := epubname.Check(c.Options.OutputTemplate); err != nil {
+			return err
+		}
+	}
+
+	// Split
+	if c.Options.SplitMode < 0 || c.Options.SplitMode > 3 {
+		return errors.New("split mode should be 0, 1, 2 or 3")
+	}
+	if c.Options.SplitMode == 2 && c.Options.SplitPages <= 0 {
+		return errors.New("split pages should be > 0 with split mode 2")
+	}
+	if c.Options.SplitMode == 3 && c.Options.LimitMb == 0 {
+		return errors.New("limitmb is required with split mode 3")
+	}
+
 	// Brightness
 	if c.Options.Image.Brightness < -100 || c.Options.Image.Brightness > 100 {
 		return errors.New("brightness should be between -100 and 100")
@@ -399,10 +849,43 @@ func (c *Converter) Validate() error {
 		return errors.New("grayscale mode should be 0, 1 or 2")
 	}
 
+	// Dry sample
+	if c.Options.DrySample < 0 || c.Options.DrySample > 100 {
+		return errors.New("dry sample should be between 0 and 100")
+	}
+
 	// crop
 	if c.Options.Image.Crop.Limit < 0 || c.Options.Image.Crop.Limit > 100 {
 		return errors.New("crop limit should be between 0 and 100")
 	}
+	if c.Options.Image.Crop.Color != "auto" && !colorRegex.MatchString(c.Options.Image.Crop.Color) {
+		return errors.New("crop color must be auto or have color format in hexadecimal: [0-9A-F]{3}")
+	}
+	if c.Options.Image.Crop.Tolerance < 0 || c.Options.Image.Crop.Tolerance > 255 {
+		return errors.New("crop tolerance should be between 0 and 255")
+	}
+	if c.Options.Image.Crop.SpeckArea < 0 || c.Options.Image.Crop.SpeckArea > 1000 {
+		return errors.New("crop speck area should be between 0 and 1000")
+	}
+	if c.Options.Image.Crop.SpeckDistance < 1 || c.Options.Image.Crop.SpeckDistance > 100 {
+		return errors.New("crop speck distance should be between 1 and 100")
+	}
+	if c.Options.Image.Crop.Mode < 0 || c.Options.Image.Crop.Mode > 2 {
+		return errors.New("crop mode should be 0, 1 or 2")
+	}
+	if c.Options.Image.Crop.Percentile < 0 || c.Options.Image.Crop.Percentile > 100 {
+		return errors.New("crop percentile should be between 0 and 100")
+	}
+
+	// split double page
+	if c.Options.Image.SplitDoublePageOverlap < 0 || c.Options.Image.SplitDoublePageOverlap > 10 {
+		return errors.New("split double page overlap should be between 0 and 10")
+	}
+
+	// merge spread
+	if c.Options.Image.MergeSpread && c.Options.Image.Format == "copy" {
+		return errors.New("format copy doesn't support merge spread")
+	}
 
 	return nil
 }
@@ -419,15 +902,11 @@ func (c *Converter) Stats() {
 	var mem runtime.MemStats
 	runtime.ReadMemStats(&mem)
 
-	if c.Options.Json {
-		_ = json.NewEncoder(os.Stdout).Encode(map[string]any{
-			"type": "stats",
-			"data": map[string]any{
-				"elapse_ms":       elapse.Milliseconds(),
-				"memory_usage_mb": mem.Sys / 1024 / 1024,
-			},
-		})
-	} else {
+	epubevent.Emit(epubevent.TypeStats, map[string]any{
+		"elapse_ms":       elapse.Milliseconds(),
+		"memory_usage_mb": mem.Sys / 1024 / 1024,
+	})
+	if !c.Options.Json {
 		utils.Printf(
 			"Completed in %s, Memory usage %d Mb\n",
 			elapse,
@@ -0,0 +1,32 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvPrefix prefix of the environment variables
+const EnvPrefix = "GCC_"
+
+// EnvName environment variable of a config key: image.quality => GCC_IMAGE_QUALITY
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// load the environment variables set, they override the config file.
+func (o *Options) loadEnv() error {
+	for _, key := range o.Keys() {
+		name := EnvName(key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := o.Set(key, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		path, _ := o.keyPath(key)
+		o.SetOrigin("env "+name, path)
+	}
+	return nil
+}
@@ -2,9 +2,14 @@
 package converter
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -14,10 +19,20 @@ import (
 )
 
 type Options struct {
+	SchemaVersion int `yaml:"version,omitempty" json:"-"`
+
 	epuboptions.EPUBOptions
 
+	// Output
+	DeliverToDevice bool `yaml:"-" json:"-"`
+	Force           bool `yaml:"-" json:"-"`
+
 	// Config
-	Profile string `yaml:"profile" json:"profile"`
+	ConfigFile     string               `yaml:"-" json:"-"`
+	Profile        string               `yaml:"profile" json:"profile"`
+	Preset         string               `yaml:"-" json:"preset,omitempty"`
+	Presets        map[string]yaml.Node `yaml:"presets,omitempty" json:"-"`
+	CustomProfiles []Profile            `yaml:"profiles,omitempty" json:"-"`
 
 	// Default Config
 	Show  bool `yaml:"-" json:"-"`
@@ -32,12 +47,21 @@ type Options struct {
 	GreatQuality bool `yaml:"-" json:"-"`
 	GoodQuality  bool `yaml:"-" json:"-"`
 
+	// OPDS
+	OpdsServe string `yaml:"-" json:"-"`
+	OpdsAddr  string `yaml:"-" json:"-"`
+
 	// Other
+	Inspect bool `yaml:"-" json:"-"`
 	Version bool `yaml:"-" json:"-"`
 	Help    bool `yaml:"-" json:"-"`
 
 	// Internal
 	profiles Profiles
+	sources  []string
+	origins  map[string]int
+	// config file with the v2 layout, migrated in memory
+	migrated bool
 }
 
 // NewOptions Initialize default options.
@@ -49,11 +73,15 @@ func NewOptions() *Options {
 				Quality:   85,
 				GrayScale: true,
 				Crop: epuboptions.Crop{
-					Enabled: true,
-					Left:    1,
-					Up:      1,
-					Right:   1,
-					Bottom:  3,
+					Enabled:       true,
+					Left:          1,
+					Up:            1,
+					Right:         1,
+					Bottom:        3,
+					Percentile:    10,
+					Color:         "FFF",
+					Tolerance:     31,
+					SpeckDistance: 2,
 				},
 				NoBlankImage:              true,
 				HasCover:                  true,
@@ -70,6 +98,7 @@ func NewOptions() *Options {
 			},
 			TitlePage:    1,
 			SortPathMode: 1,
+			Overwrite:    "always",
 		},
 		profiles: NewProfiles(),
 	}
@@ -94,36 +123,100 @@ func (o *Options) String() string {
 	} {
 		b.WriteString(fmt.Sprintf("\n    %-32s: %v", v.K, v.V))
 	}
+	for i, source := range o.Inputs {
+		b.WriteString(fmt.Sprintf("\n    %-32s: %s (%s)", fmt.Sprintf("Source %d", i+1), source.Title, source.Path))
+	}
 	b.WriteString(o.ShowConfig())
 	b.WriteRune('\n')
 	return b.String()
 }
 
-// FileName Config file: ~/.go-comic-converter.yaml
+// FileName Config file: -config, or ~/.go-comic-converter.yaml
 func (o *Options) FileName() string {
+	if o.ConfigFile != "" {
+		return o.ConfigFile
+	}
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".go-comic-converter.yaml")
 }
 
-// LoadConfig Load config files
+// LoadConfig Load config files, then the preset if one is selected, then the environment variables
 func (o *Options) LoadConfig() error {
-	f, err := os.Open(o.FileName())
+	if err := o.loadFile(); err != nil {
+		return err
+	}
+
+	if err := o.profiles.Add(o.CustomProfiles); err != nil {
+		return err
+	}
+
+	// unknown preset is allowed to save a new one
+	if preset, ok := o.Presets[o.Preset]; ok && o.Preset != "" {
+		if err := o.decodeLayer(&preset, "preset "+o.Preset); err != nil {
+			return fmt.Errorf("preset %q: %w", o.Preset, err)
+		}
+	}
+
+	return o.loadEnv()
+}
+
+// new options using the same config file
+func (o *Options) newBase() *Options {
+	base := NewOptions()
+	base.ConfigFile = o.ConfigFile
+	return base
+}
+
+// load the config file into the options, only the fields set are changed.
+//
+// A v2 config is migrated in memory, use MigrateConfig to rewrite the file.
+func (o *Options) loadFile() error {
+	node, err := readNode(o.FileName())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
+		return fmt.Errorf("%s: %w", o.FileName(), err)
+	}
+	if node == nil {
 		return nil
 	}
-	defer func(f *os.File) {
-		_ = f.Close()
-	}(f)
-	err = yaml.NewDecoder(f).Decode(o)
-	if err != nil && err.Error() != "EOF" {
-		return err
+	if version, err := configVersion(node); err == nil && version == 2 {
+		if node, err = migrateNode(node, o.FileName()); err != nil {
+			return err
+		}
+		o.migrated = true
 	}
+	return o.decodeNode(node, o.FileName(), o.FileName())
+}
 
-	return nil
+// HasPreset check if the selected preset exists
+func (o *Options) HasPreset() bool {
+	_, ok := o.Presets[o.Preset]
+	return ok
+}
+
+// AvailablePresets names of the presets
+func (o *Options) AvailablePresets() []string {
+	names := make([]string, 0, len(o.Presets))
+	for name := range o.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // ShowConfig Get current settings for fields that can be saved
 func (o *Options) ShowConfig() string {
+	return o.showConfig(false)
+}
+
+// ShowEffectiveConfig Get current settings with the origin of each value: default, config, preset, sidecar or flag
+func (o *Options) ShowEffectiveConfig() string {
+	return o.showConfig(true)
+}
+
+func (o *Options) showConfig(withOrigin bool) string {
 	var profileDesc string
 	profile := o.GetProfile()
 	if profile != nil {
@@ -140,6 +233,31 @@ func (o *Options) ShowConfig() string {
 		sortpathmode = "path=alphanumeric, file=alphanumeric"
 	}
 
+	cropColor := "#" + o.Image.Crop.Color
+	if o.Image.Crop.Color == "auto" {
+		cropColor = "auto"
+	}
+
+	cropMode := "each page"
+	switch o.Image.Crop.Mode {
+	case 1:
+		cropMode = "all pages, percentile " + utils.IntToString(o.Image.Crop.Percentile)
+	case 2:
+		cropMode = "left and right pages, percentile " + utils.IntToString(o.Image.Crop.Percentile)
+	}
+
+	splitMode := ""
+	switch o.SplitMode {
+	case 0:
+		splitMode = "size"
+	case 1:
+		splitMode = "top level directory"
+	case 2:
+		splitMode = "page count"
+	case 3:
+		splitMode = "size at the nearest chapter"
+	}
+
 	aspectRatio := "auto"
 	if o.Image.View.AspectRatio > 0 {
 		aspectRatio = "1:" + utils.FloatToString(o.Image.View.AspectRatio, 2)
@@ -174,13 +292,15 @@ func (o *Options) ShowConfig() string {
 		Key       string
 		Value     any
 		Condition bool
+		Path      string
 	}{
-		{"Profile", profileDesc, true},
-		{"Format", o.Image.Format, true},
-		{"Quality", o.Image.Quality, o.Image.Format == "jpeg"},
-		{"Grayscale", o.Image.GrayScale, o.Image.Format != "copy"},
-		{"Grayscale mode", grayscaleMode, o.Image.Format != "copy" && o.Image.GrayScale},
-		{"Crop", o.Image.Crop.Enabled, o.Image.Format != "copy"},
+		{"Preset", o.Preset, o.Preset != "", ""},
+		{"Profile", profileDesc, true, "profile"},
+		{"Format", o.Image.Format, true, "epuboptions.image.format"},
+		{"Quality", o.Image.Quality, o.Image.Format == "jpeg", "epuboptions.image.quality"},
+		{"Grayscale", o.Image.GrayScale, o.Image.Format != "copy", "epuboptions.image.grayscale"},
+		{"Grayscale mode", grayscaleMode, o.Image.Format != "copy" && o.Image.GrayScale, "epuboptions.image.grayscale_mode"},
+		{"Crop", o.Image.Crop.Enabled, o.Image.Format != "copy", "epuboptions.image.crop.enabled"},
 		{"Crop ratio",
 			utils.IntToString(o.Image.Crop.Left) + " Left - " +
 				utils.IntToString(o.Image.Crop.Up) + " Up - " +
@@ -188,45 +308,152 @@ func (o *Options) ShowConfig() string {
 				utils.IntToString(o.Image.Crop.Bottom) + " Bottom - " +
 				"Limit " + utils.IntToString(o.Image.Crop.Limit) + "% - " +
 				"Skip " + utils.BoolToString(o.Image.Crop.SkipIfLimitReached),
-			o.Image.Format != "copy" && o.Image.Crop.Enabled},
-		{"Brightness", o.Image.Brightness, o.Image.Format != "copy" && o.Image.Brightness != 0},
-		{"Contrast", o.Image.Contrast, o.Image.Format != "copy" && o.Image.Contrast != 0},
-		{"Auto contrast", o.Image.AutoContrast, o.Image.Format != "copy"},
-		{"Auto rotate", o.Image.AutoRotate, o.Image.Format != "copy"},
-		{"Auto split double page", o.Image.AutoSplitDoublePage, o.Image.Format != "copy" && (o.Image.View.PortraitOnly || !o.Image.AppleBookCompatibility)},
-		{"Keep double page if split", o.Image.KeepDoublePageIfSplit, o.Image.Format != "copy" && (o.Image.View.PortraitOnly || !o.Image.AppleBookCompatibility) && o.Image.AutoSplitDoublePage},
-		{"Keep split double page aspect", o.Image.KeepSplitDoublePageAspect, o.Image.Format != "copy" && (o.Image.View.PortraitOnly || !o.Image.AppleBookCompatibility) && o.Image.AutoSplitDoublePage},
-		{"No blank image", o.Image.NoBlankImage, o.Image.Format != "copy"},
-		{"Manga", o.Image.Manga, true},
-		{"Has cover", o.Image.HasCover, true},
-		{"Limit", utils.IntToString(o.LimitMb) + " Mb", o.LimitMb != 0},
-		{"Strip first directory from toc", o.StripFirstDirectoryFromToc, true},
-		{"Sort path mode", sortpathmode, true},
-		{"Foreground color", "#" + o.Image.View.Color.Foreground, true},
-		{"Background color", "#" + o.Image.View.Color.Background, true},
-		{"Resize", o.Image.Resize, o.Image.Format != "copy"},
-		{"Aspect ratio", aspectRatio, true},
-		{"Portrait only", o.Image.View.PortraitOnly, true},
-		{"Title page", titlePage, true},
-		{"Apple book compatibility", o.Image.AppleBookCompatibility, !o.Image.View.PortraitOnly},
+			o.Image.Format != "copy" && o.Image.Crop.Enabled, "epuboptions.image.crop"},
+		{"Crop color", cropColor + " - Tolerance " + utils.IntToString(o.Image.Crop.Tolerance),
+			o.Image.Format != "copy" && (o.Image.Crop.Enabled || o.Image.NoBlankImage) && (o.Image.Crop.Color != "FFF" || o.Image.Crop.Tolerance != 31), "epuboptions.image.crop.color"},
+		{"Crop specks", "Area " + utils.IntToString(o.Image.Crop.SpeckArea) + "‰ - Distance " + utils.IntToString(o.Image.Crop.SpeckDistance) + "% - Remove " + utils.BoolToString(o.Image.Crop.RemoveSpecks),
+			o.Image.Format != "copy" && o.Image.Crop.SpeckArea > 0, "epuboptions.image.crop.speck_area"},
+		{"Crop mode", cropMode, o.Image.GlobalCrop(), "epuboptions.image.crop.mode"},
+		{"Brightness", o.Image.Brightness, o.Image.Format != "copy" && o.Image.Brightness != 0, "epuboptions.image.brightness"},
+		{"Contrast", o.Image.Contrast, o.Image.Format != "copy" && o.Image.Contrast != 0, "epuboptions.image.contrast"},
+		{"Auto contrast", o.Image.AutoContrast, o.Image.Format != "copy", "epuboptions.image.auto_contrast"},
+		{"Auto rotate", o.Image.AutoRotate, o.Image.Format != "copy", "epuboptions.image.auto_rotate"},
+		{"Auto split double page", o.Image.AutoSplitDoublePage, o.Image.Format != "copy" && (o.Image.View.PortraitOnly || !o.Image.AppleBookCompatibility), "epuboptions.image.auto_split_double_page"},
+		{"Keep double page if split", o.Image.KeepDoublePageIfSplit, o.Image.Format != "copy" && (o.Image.View.PortraitOnly || !o.Image.AppleBookCompatibility) && o.Image.AutoSplitDoublePage, "epuboptions.image.keep_double_page_if_split"},
+		{"Keep split double page aspect", o.Image.KeepSplitDoublePageAspect, o.Image.Format != "copy" && (o.Image.View.PortraitOnly || !o.Image.AppleBookCompatibility) && o.Image.AutoSplitDoublePage, "epuboptions.image.keep_split_double_page_aspect"},
+		{"Split double page at gutter", "Overlap " + utils.IntToString(o.Image.SplitDoublePageOverlap) + "% - Trim " + utils.BoolToString(o.Image.SplitDoublePageTrimGutter),
+			o.Image.Format != "copy" && (o.Image.View.PortraitOnly || !o.Image.AppleBookCompatibility) && o.Image.AutoSplitDoublePage && o.Image.SplitDoublePageGutter, "epuboptions.image.split_double_page_gutter"},
+		{"Detect spread", o.Image.DetectSpread, o.Image.Format != "copy", "epuboptions.image.detect_spread"},
+		{"Merge spread", o.Image.MergeSpread, o.Image.Format != "copy" && !o.Image.View.PortraitOnly, "epuboptions.image.merge_spread"},
+		{"No blank image", o.Image.NoBlankImage, o.Image.Format != "copy", "epuboptions.image.no_blank_image"},
+		{"Manga", o.Image.Manga, true, "epuboptions.image.manga"},
+		{"Has cover", o.Image.HasCover, true, "epuboptions.image.has_cover"},
+		{"Overwrite", o.Overwrite, o.Overwrite != "always", "epuboptions.overwrite"},
+		{"Reproducible", o.Reproducible, o.Reproducible, "epuboptions.reproducible"},
+		{"Output template", o.OutputTemplate, o.OutputTemplate != "", "epuboptions.output_template"},
+		{"Limit", utils.IntToString(o.LimitMb) + " Mb", o.LimitMb != 0, "epuboptions.limit_mb"},
+		{"Split mode", splitMode, o.SplitMode != 0, "epuboptions.split_mode"},
+		{"Split pages", o.SplitPages, o.SplitMode == 2, "epuboptions.split_pages"},
+		{"Strip first directory from toc", o.StripFirstDirectoryFromToc, true, "epuboptions.strip_first_directory"},
+		{"Sort path mode", sortpathmode, true, "epuboptions.sort_path_mode"},
+		{"Foreground color", "#" + o.Image.View.Color.Foreground, true, "epuboptions.image.view.color.foreground"},
+		{"Background color", "#" + o.Image.View.Color.Background, true, "epuboptions.image.view.color.background"},
+		{"Resize", o.Image.Resize, o.Image.Format != "copy", "epuboptions.image.resize"},
+		{"Aspect ratio", aspectRatio, true, "epuboptions.image.view.aspect_ratio"},
+		{"Portrait only", o.Image.View.PortraitOnly, true, "epuboptions.image.view.portrait_only"},
+		{"Title page", titlePage, true, "epuboptions.title_page"},
+		{"Apple book compatibility", o.Image.AppleBookCompatibility, !o.Image.View.PortraitOnly, "epuboptions.image.apple_book_compatibility"},
 	} {
-		if v.Condition {
+		if !v.Condition {
+			continue
+		}
+		if withOrigin && v.Path != "" {
+			b.WriteString(fmt.Sprintf("\n    %-32s: %-40v (%s)", v.Key, v.Value, o.Origin(v.Path)))
+		} else {
 			b.WriteString(fmt.Sprintf("\n    %-32s: %v", v.Key, v.Value))
 		}
 	}
 	return b.String()
 }
 
-// ResetConfig reset all settings to default value
+// ResetConfig reset all settings to default value.
+//
+// With a preset, only the preset is removed. Otherwise, the presets and the custom profiles are kept.
 func (o *Options) ResetConfig() error {
-	if err := NewOptions().SaveConfig(); err != nil {
+	base := o.newBase()
+	if err := base.loadFile(); err != nil {
 		return err
 	}
+
+	name := o.Preset
+	reset := o.newBase()
+	if name != "" {
+		reset = base
+		delete(reset.Presets, name)
+	} else {
+		reset.Presets = base.Presets
+		reset.CustomProfiles = base.CustomProfiles
+	}
+	if err := reset.writeFile(); err != nil {
+		return err
+	}
+
+	*o = *o.newBase()
+	o.Preset = name
 	return o.LoadConfig()
 }
 
-// SaveConfig save all current settings as default value
+// SaveConfig save all current settings as default value, or into the selected preset.
+//
+// The values of the environment variables and of the sidecar files only apply to the run, they are not saved.
 func (o *Options) SaveConfig() error {
+	saved, err := o.savedOptions()
+	if err != nil {
+		return err
+	}
+	if o.Preset == "" {
+		return saved.writeFile()
+	}
+
+	base := o.newBase()
+	if err := base.loadFile(); err != nil {
+		return err
+	}
+
+	preset := *saved
+	preset.SchemaVersion = 0
+	preset.Presets = nil
+	preset.CustomProfiles = nil
+	var node yaml.Node
+	if err := node.Encode(&preset); err != nil {
+		return err
+	}
+	if base.Presets == nil {
+		base.Presets = map[string]yaml.Node{}
+	}
+	base.Presets[o.Preset] = node
+	return base.writeFile()
+}
+
+// savedOrigin check if a value from this origin is saved: set on the command line, or with config set.
+func savedOrigin(source string) bool {
+	return strings.HasPrefix(source, "flag -") || source == "shortcut" || source == "config set"
+}
+
+// savedOptions the config file and the selected preset, with the values set on the command line or with config set.
+func (o *Options) savedOptions() (*Options, error) {
+	saved := o.newBase()
+	if err := saved.loadFile(); err != nil {
+		return nil, err
+	}
+	if preset, ok := saved.Presets[o.Preset]; ok && o.Preset != "" {
+		if err := saved.decodeLayer(&preset, "preset "+o.Preset); err != nil {
+			return nil, fmt.Errorf("preset %q: %w", o.Preset, err)
+		}
+	}
+
+	values := map[string]reflect.Value{}
+	o.fields(func(path string, v reflect.Value) {
+		if savedOrigin(o.Origin(path)) {
+			values[path] = v
+		}
+	})
+	saved.fields(func(path string, v reflect.Value) {
+		if value, ok := values[path]; ok {
+			v.Set(value)
+		}
+	})
+	saved.Preset = o.Preset
+	return saved, nil
+}
+
+// write the options into the config file
+//
+// A v2 config file is backed up before being replaced.
+func (o *Options) writeFile() error {
+	if _, err := backupV2(o.FileName()); err != nil {
+		return err
+	}
 	f, err := os.Create(o.FileName())
 	if err != nil {
 		return err
@@ -234,6 +461,7 @@ func (o *Options) SaveConfig() error {
 	defer func(f *os.File) {
 		_ = f.Close()
 	}(f)
+	o.SchemaVersion = ConfigVersion
 	return yaml.NewEncoder(f).Encode(o)
 }
 
@@ -245,6 +473,31 @@ func (o *Options) GetProfile() *Profile {
 	return nil
 }
 
+// OptionsHash hash of the options that change the EPUB, used to detect that a conversion should be done again
+func (o *Options) OptionsHash() string {
+	values := make([]string, 0)
+	o.fields(func(k string, v reflect.Value) {
+		if isSetting(k, v) {
+			values = append(values, k+"="+fmt.Sprintf("%v", v.Interface()))
+		}
+	})
+	sort.Strings(values)
+	if p := o.GetProfile(); p != nil {
+		values = append(values, fmt.Sprintf("profile=%dx%d", p.Width, p.Height))
+	}
+	values = append(values, "output="+o.Output, "author="+o.Author, "title="+o.Title)
+	for _, source := range o.Inputs {
+		values = append(values, "input="+source.Path)
+	}
+	h := sha256.Sum256([]byte(strings.Join(values, "\n")))
+	return hex.EncodeToString(h[:])
+}
+
+// Profiles all available profiles, sorted by code
+func (o *Options) Profiles() []Profile {
+	return o.profiles.Sorted()
+}
+
 // AvailableProfiles all available profiles
 func (o *Options) AvailableProfiles() string {
 	return o.profiles.String()
@@ -3,20 +3,59 @@ package converter
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
 )
 
 type Profile struct {
-	Code        string `json:"code"`
-	Description string `json:"description"`
-	Width       int    `json:"width"`
-	Height      int    `json:"height"`
+	Code        string `yaml:"code" json:"code"`
+	Description string `yaml:"description" json:"description"`
+	Width       int    `yaml:"width" json:"width"`
+	Height      int    `yaml:"height" json:"height"`
+
+	// Capabilities, unset means unknown
+	Color      *bool    `yaml:"color,omitempty" json:"color,omitempty"`
+	Formats    []string `yaml:"formats,omitempty" json:"formats,omitempty"`
+	GrayLevels int      `yaml:"gray_levels,omitempty" json:"gray_levels,omitempty"`
 }
 
 func (p Profile) String() string {
-	return p.Code + " - " + p.Description + " - " + utils.IntToString(p.Width) + "x" + utils.IntToString(p.Height)
+	s := p.Code + " - " + p.Description + " - " + utils.IntToString(p.Width) + "x" + utils.IntToString(p.Height)
+	if c := p.Capabilities(); c != "" {
+		s += " - " + c
+	}
+	return s
+}
+
+// Capabilities human-readable version of the capabilities
+func (p Profile) Capabilities() string {
+	var c []string
+	if p.Color != nil {
+		if *p.Color {
+			c = append(c, "color")
+		} else {
+			c = append(c, "monochrome")
+		}
+	}
+	if p.GrayLevels > 0 {
+		c = append(c, utils.IntToString(p.GrayLevels)+" gray levels")
+	}
+	if len(p.Formats) > 0 {
+		c = append(c, strings.Join(p.Formats, "/"))
+	}
+	return strings.Join(c, ", ")
+}
+
+// SupportColor check if the device display colors, unknown capabilities support colors.
+func (p Profile) SupportColor() bool {
+	return p.Color == nil || *p.Color
+}
+
+// SupportFormat check if the device support the format, unknown capabilities support all formats.
+func (p Profile) SupportFormat(format string) bool {
+	return len(p.Formats) == 0 || slices.Contains(p.Formats, format)
 }
 
 type Profiles map[string]Profile
@@ -24,7 +63,10 @@ type Profiles map[string]Profile
 // NewProfiles Initialize list of all supported profiles.
 func NewProfiles() Profiles {
 	res := make(Profiles)
-	for _, r := range []Profile{
+	for _, r := range []struct {
+		Code, Description string
+		Width, Height     int
+	}{
 		// High Resolution for Tablet
 		{"HR", "High Resolution", 2400, 3840},
 		{"SR", "Standard Resolution", 1200, 1920},
@@ -58,19 +100,53 @@ func NewProfiles() Profiles {
 		{"RM1", "reMarkable 1", 1404, 1872},
 		{"RM2", "reMarkable 2", 1404, 1872},
 	} {
-		res[r.Code] = r
+		res[r.Code] = Profile{Code: r.Code, Description: r.Description, Width: r.Width, Height: r.Height}
 	}
 	return res
 }
 
+// Add custom profiles, they replace the built-in profiles with the same code.
+//
+// A profile without code or with an invalid size is rejected.
+func (p Profiles) Add(profiles []Profile) error {
+	for i, r := range profiles {
+		switch {
+		case r.Code == "":
+			return fmt.Errorf("profile #%d: code missing", i+1)
+		case r.Width <= 0 || r.Height <= 0:
+			return fmt.Errorf("profile %q: width and height should be > 0", r.Code)
+		case r.GrayLevels < 0 || r.GrayLevels == 1:
+			return fmt.Errorf("profile %q: gray levels should be 0 or >= 2", r.Code)
+		}
+		p[r.Code] = r
+	}
+	return nil
+}
+
+// Sorted profiles by code
+func (p Profiles) Sorted() []Profile {
+	s := make([]Profile, 0, len(p))
+	for _, v := range p {
+		s = append(s, v)
+	}
+	slices.SortFunc(s, func(a, b Profile) int {
+		return strings.Compare(a.Code, b.Code)
+	})
+	return s
+}
+
 func (p Profiles) String() string {
 	s := make([]string, 0)
-	for _, v := range p {
+	for _, v := range p.Sorted() {
+		desc := v.Description
+		if c := v.Capabilities(); c != "" {
+			desc += " (" + c + ")"
+		}
 		s = append(s, fmt.Sprintf(
 			"    - %-7s - %4d x %-4d - %s",
 			v.Code,
 			v.Width, v.Height,
-			v.Description,
+			desc,
 		))
 	}
 	return strings.Join(s, "\n")
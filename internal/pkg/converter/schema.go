@@ -0,0 +1,266 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigVersion version of the config file schema
+//
+// A config without version use the v3 layout if it has an epuboptions section, the v2 flat layout otherwise.
+const ConfigVersion = 3
+
+// v2 flat keys and their path in the current layout
+var configV2Keys = map[string]string{
+	"profile":                        "profile",
+	"quality":                        "epuboptions.image.quality",
+	"grayscale":                      "epuboptions.image.grayscale",
+	"grayscale_mode":                 "epuboptions.image.grayscale_mode",
+	"crop":                           "epuboptions.image.crop.enabled",
+	"crop_ratio_left":                "epuboptions.image.crop.left",
+	"crop_ratio_up":                  "epuboptions.image.crop.up",
+	"crop_ratio_right":               "epuboptions.image.crop.right",
+	"crop_ratio_bottom":              "epuboptions.image.crop.bottom",
+	"crop_limit":                     "epuboptions.image.crop.limit",
+	"crop_skip_if_limit_reached":     "epuboptions.image.crop.skip_if_limit_reached",
+	"brightness":                     "epuboptions.image.brightness",
+	"contrast":                       "epuboptions.image.contrast",
+	"auto_contrast":                  "epuboptions.image.auto_contrast",
+	"auto_rotate":                    "epuboptions.image.auto_rotate",
+	"auto_split_double_page":         "epuboptions.image.auto_split_double_page",
+	"keep_double_page_if_split":      "epuboptions.image.keep_double_page_if_split",
+	"keep_split_double_page_aspect":  "epuboptions.image.keep_split_double_page_aspect",
+	"no_blank_image":                 "epuboptions.image.no_blank_image",
+	"manga":                          "epuboptions.image.manga",
+	"has_cover":                      "epuboptions.image.has_cover",
+	"limit_mb":                       "epuboptions.limit_mb",
+	"strip_first_directory_from_toc": "epuboptions.strip_first_directory",
+	"sort_path_mode":                 "epuboptions.sort_path_mode",
+	"foreground_color":               "epuboptions.image.view.color.foreground",
+	"background_color":               "epuboptions.image.view.color.background",
+	"noresize":                       "epuboptions.image.resize",
+	"format":                         "epuboptions.image.format",
+	"aspect_ratio":                   "epuboptions.image.view.aspect_ratio",
+	"portrait_only":                  "epuboptions.image.view.portrait_only",
+	"apple_book_compatibility":       "epuboptions.image.apple_book_compatibility",
+	"title_page":                     "epuboptions.title_page",
+}
+
+// read a yaml file, nil if empty
+func readNode(path string) (*yaml.Node, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+	var node yaml.Node
+	if err = yaml.NewDecoder(f).Decode(&node); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &node, nil
+}
+
+// root mapping of a document
+func rootMapping(node *yaml.Node) *yaml.Node {
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	return node
+}
+
+// version of the config, from the version key or the layout
+func configVersion(node *yaml.Node) (int, error) {
+	root := rootMapping(node)
+	if root == nil {
+		return ConfigVersion, nil
+	}
+	isV2 := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "version":
+			v, err := strconv.Atoi(value.Value)
+			if err != nil {
+				return 0, fmt.Errorf("%d: invalid version %q", value.Line, value.Value)
+			}
+			return v, nil
+		case "epuboptions":
+			return ConfigVersion, nil
+		case "profile":
+		default:
+			_, ok := configV2Keys[key.Value]
+			isV2 = isV2 || ok
+		}
+	}
+	if isV2 {
+		return 2, nil
+	}
+	return ConfigVersion, nil
+}
+
+// check the version and the keys of the config, all unknown keys are reported
+func checkNode(node *yaml.Node, file string) error {
+	version, err := configVersion(node)
+	if err != nil {
+		return fmt.Errorf("%s:%w", file, err)
+	}
+	if version > ConfigVersion {
+		return fmt.Errorf("%s: version %d is not supported, upgrade go-comic-converter", file, version)
+	}
+	if version < ConfigVersion {
+		return fmt.Errorf("%s: version %d should be migrated", file, version)
+	}
+	errs := unknownKeys(node, reflect.TypeOf(Options{}), "")
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("%s:%w", file, err)
+	}
+	return errors.Join(errs...)
+}
+
+// report keys of the node that doesn't exist in the type
+func unknownKeys(node *yaml.Node, t reflect.Type, prefix string) []error {
+	errs := make([]error, 0)
+	switch {
+	case node.Kind == yaml.DocumentNode:
+		for _, c := range node.Content {
+			errs = append(errs, unknownKeys(c, t, prefix)...)
+		}
+	case t == reflect.TypeOf(yaml.Node{}):
+		// presets have the same layout as the config
+		errs = append(errs, unknownKeys(node, reflect.TypeOf(Options{}), prefix)...)
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := map[string]reflect.Type{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if !f.IsExported() || tag == "-" {
+				continue
+			}
+			if tag == "" {
+				tag = strings.ToLower(f.Name)
+			}
+			fields[tag] = f.Type
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			ft, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%d: unknown key %q", key.Line, prefix+key.Value))
+				continue
+			}
+			errs = append(errs, unknownKeys(node.Content[i+1], ft, prefix+key.Value+".")...)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, unknownKeys(node.Content[i+1], t.Elem(), prefix+node.Content[i].Value+".")...)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, c := range node.Content {
+			errs = append(errs, unknownKeys(c, t.Elem(), prefix+strconv.Itoa(i)+".")...)
+		}
+	}
+	return errs
+}
+
+// convert a v2 config into the current layout, the values are checked when decoded
+func migrateNode(node *yaml.Node, file string) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	v2 := rootMapping(node)
+	for i := 0; v2 != nil && i+1 < len(v2.Content); i += 2 {
+		key, value := v2.Content[i], v2.Content[i+1]
+		if key.Value == "version" {
+			continue
+		}
+		path, ok := configV2Keys[key.Value]
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: unknown v2 key %q", file, key.Line, key.Value)
+		}
+		value = &yaml.Node{Kind: value.Kind, Tag: value.Tag, Value: value.Value, Line: value.Line}
+		if key.Value == "noresize" {
+			b, err := strconv.ParseBool(value.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid value %q", file, value.Line, value.Value)
+			}
+			value.Value = strconv.FormatBool(!b)
+		}
+		setNode(root, strings.Split(path, "."), value)
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}, nil
+}
+
+// set the value at the path of the mapping, the missing mappings are created
+func setNode(mapping *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == path[0] {
+			if len(path) == 1 {
+				mapping.Content[i+1] = value
+			} else {
+				setNode(mapping.Content[i+1], path[1:], value)
+			}
+			return
+		}
+	}
+	child := value
+	if len(path) > 1 {
+		child = &yaml.Node{Kind: yaml.MappingNode}
+		setNode(child, path[1:], value)
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}, child)
+}
+
+// keep a copy of the config file if it has the v2 layout, before it is rewritten
+func backupV2(file string) (string, error) {
+	node, err := readNode(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if version, err := configVersion(node); err != nil || version != 2 {
+		return "", err
+	}
+	backup := file + ".v2-" + time.Now().Format("20060102T150405") + ".bak"
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return backup, os.WriteFile(backup, b, 0644)
+}
+
+// MigrateConfig rewrite a v2 config file with the current layout, a backup of the file is kept.
+//
+// The config is migrated in memory when loaded, the file is only rewritten on request.
+func (o *Options) MigrateConfig() (backup string, err error) {
+	if !o.migrated {
+		return "", nil
+	}
+	base := o.newBase()
+	if err = base.loadFile(); err != nil {
+		return "", err
+	}
+	if backup, err = backupV2(o.FileName()); err != nil {
+		return "", err
+	}
+	if err = base.writeFile(); err != nil {
+		return "", err
+	}
+	o.migrated = false
+	return backup, nil
+}
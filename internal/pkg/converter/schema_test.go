@@ -0,0 +1,125 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func parseNode(s string) *yaml.Node {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &node); err != nil {
+		panic(err)
+	}
+	return &node
+}
+
+func Example_configVersion() {
+	for _, tc := range []struct {
+		name, config string
+	}{
+		{"version", "version: 3\nprofile: SR\n"},
+		{"future version", "version: 4\n"},
+		{"invalid version", "version: three\n"},
+		{"v3 layout", "profile: SR\nepuboptions:\n  limit_mb: 200\n"},
+		{"v2 layout", "profile: SR\nquality: 90\n"},
+		{"profile only", "profile: SR\n"},
+		{"not a mapping", "- SR\n"},
+	} {
+		v, err := configVersion(parseNode(tc.config))
+		fmt.Println(tc.name, v, err)
+	}
+	// Output: version 3 <nil>
+	// future version 4 <nil>
+	// invalid version 0 1: invalid version "three"
+	// v3 layout 3 <nil>
+	// v2 layout 2 <nil>
+	// profile only 3 <nil>
+	// not a mapping 3 <nil>
+}
+
+func Example_migrateNode() {
+	for _, config := range []string{
+		"profile: SR\nquality: 90\ngrayscale: false\nnoresize: true\ncrop_ratio_left: 5\n",
+		"version: 2\nprofile: KS\n",
+		"quality: 90\nunknown: 1\n",
+		"noresize: maybe\n",
+	} {
+		node, err := migrateNode(parseNode(config), "config.yaml")
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		b, _ := yaml.Marshal(node)
+		fmt.Print(string(b))
+	}
+	// Output: profile: SR
+	// epuboptions:
+	//     image:
+	//         quality: 90
+	//         grayscale: false
+	//         resize: false
+	//         crop:
+	//             left: 5
+	// profile: KS
+	// config.yaml:2: unknown v2 key "unknown"
+	// config.yaml:1: invalid value "maybe"
+}
+
+func Example_checkNode() {
+	for _, config := range []string{
+		"version: 3\nprofile: SR\nepuboptions:\n  limit_mb: 200\n  image:\n    quality: 90\n",
+		"version: 3\nprofil: SR\nepuboptions:\n  image:\n    qualty: 90\n    crop:\n      left: 1\n      leftt: 2\n",
+		"version: 3\npresets:\n  kindle:\n    profile: KS\n    epuboptions:\n      imag: {}\n",
+		"version: 3\nprofiles:\n  - code: MY\n    widht: 100\n",
+		"version: 2\n",
+		"version: 4\n",
+	} {
+		fmt.Println(strings.ReplaceAll(fmt.Sprint(checkNode(parseNode(config), "config.yaml")), "\n", " | "))
+	}
+	// Output: <nil>
+	// config.yaml:2: unknown key "profil" | config.yaml:5: unknown key "epuboptions.image.qualty" | config.yaml:8: unknown key "epuboptions.image.crop.leftt"
+	// config.yaml:6: unknown key "presets.kindle.epuboptions.imag"
+	// config.yaml:4: unknown key "profiles.0.widht"
+	// config.yaml: version 2 should be migrated
+	// config.yaml: version 4 is not supported, upgrade go-comic-converter
+}
+
+func ExampleOptions_MigrateConfig() {
+	dir, _ := os.MkdirTemp("", "converter")
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	file := filepath.Join(dir, "config.yaml")
+	_ = os.WriteFile(file, []byte("profile: KS\nquality: 70\nmanga: true\n"), 0644)
+
+	o := NewOptions()
+	o.ConfigFile = file
+	if err := o.LoadConfig(); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(o.Profile, o.Image.Quality, o.Image.Manga)
+
+	backup, err := o.MigrateConfig()
+	fmt.Println(strings.HasPrefix(backup, file+".v2-"), err)
+	node, _ := readNode(file)
+	version, _ := configVersion(node)
+	fmt.Println(version, checkNode(node, file))
+
+	// already migrated
+	backup, err = o.MigrateConfig()
+	fmt.Printf("%q %v\n", backup, err)
+
+	o = NewOptions()
+	o.ConfigFile = file
+	_ = o.LoadConfig()
+	fmt.Println(o.Profile, o.Image.Quality, o.Image.Manga)
+	// Output: KS 70 true
+	// true <nil>
+	// 3 <nil>
+	// "" <nil>
+	// KS 70 true
+}
@@ -0,0 +1,186 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SidecarName name of the config file placed in a series directory
+const SidecarName = ".go-comic-converter.yaml"
+
+const defaultOrigin = "default"
+
+// decode a layer of config into the options, and remember the origin of each value set.
+func (o *Options) decodeLayer(node *yaml.Node, source string) error {
+	if err := node.Decode(o); err != nil {
+		return err
+	}
+	if o.origins == nil {
+		o.origins = map[string]int{}
+	}
+	o.sources = append(o.sources, source)
+	layer := len(o.sources)
+	var walk func(n *yaml.Node, prefix string)
+	walk = func(n *yaml.Node, prefix string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, prefix)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				walk(n.Content[i+1], prefix+n.Content[i].Value+".")
+			}
+		default:
+			o.origins[strings.TrimSuffix(prefix, ".")] = layer
+		}
+	}
+	walk(node, "")
+	return nil
+}
+
+// decode a yaml file as a layer of config, the keys are checked before.
+func (o *Options) decodeFile(path string, source string) error {
+	node, err := readNode(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return o.decodeNode(node, path, source)
+}
+
+// decode a yaml document as a layer of config, after checking its keys.
+func (o *Options) decodeNode(node *yaml.Node, path string, source string) error {
+	if node == nil {
+		return nil
+	}
+	if err := checkNode(node, path); err != nil {
+		return err
+	}
+	return o.decodeLayer(node, source)
+}
+
+// SetOrigin set the origin of values
+func (o *Options) SetOrigin(source string, paths ...string) {
+	if o.origins == nil {
+		o.origins = map[string]int{}
+	}
+	o.sources = append(o.sources, source)
+	for _, path := range paths {
+		o.origins[path] = len(o.sources)
+	}
+}
+
+// Origin of the value at the yaml path, or of the last value set under it.
+func (o *Options) Origin(path string) string {
+	layer := o.origins[path]
+	for k, v := range o.origins {
+		if strings.HasPrefix(k, path+".") && v > layer {
+			layer = v
+		}
+	}
+	if layer == 0 {
+		return defaultOrigin
+	}
+	return o.sources[layer-1]
+}
+
+// call fn for each value that can be saved, with its yaml path
+func (o *Options) fields(fn func(path string, v reflect.Value)) {
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			tag := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if tag == "-" {
+				continue
+			}
+			if tag == "" {
+				tag = strings.ToLower(f.Name)
+			}
+			if f.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), prefix+tag+".")
+				continue
+			}
+			fn(prefix+tag, v.Field(i))
+		}
+	}
+	walk(reflect.ValueOf(o).Elem(), "")
+}
+
+// FieldPath yaml path of a field of the options, from its pointer
+func (o *Options) FieldPath(p any) (path string, ok bool) {
+	ptr := reflect.ValueOf(p).Pointer()
+	o.fields(func(k string, v reflect.Value) {
+		if v.Addr().Pointer() == ptr {
+			path, ok = k, true
+		}
+	})
+	return
+}
+
+// Values of the fields that can be saved, by yaml path
+func (o *Options) Values() map[string]string {
+	values := map[string]string{}
+	o.fields(func(k string, v reflect.Value) {
+		values[k] = fmt.Sprintf("%v", v.Interface())
+	})
+	return values
+}
+
+// SidecarFiles config files that override the global config for the input.
+//
+// For a directory: <input>/.go-comic-converter.yaml
+// For an archive: .go-comic-converter.yaml of the series directory, then <archive>.yaml
+func (o *Options) SidecarFiles() []string {
+	fi, err := os.Stat(o.Input)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	if fi.IsDir() {
+		candidates = []string{filepath.Join(o.Input, SidecarName)}
+	} else {
+		candidates = []string{
+			filepath.Join(filepath.Dir(o.Input), SidecarName),
+			strings.TrimSuffix(o.Input, filepath.Ext(o.Input)) + ".yaml",
+			o.Input + ".yaml",
+		}
+	}
+
+	global, _ := filepath.Abs(o.FileName())
+	files := make([]string, 0)
+	for _, c := range candidates {
+		if abs, _ := filepath.Abs(c); abs == global {
+			continue
+		}
+		if fi, err := os.Stat(c); err == nil && !fi.IsDir() {
+			files = append(files, c)
+		}
+	}
+	return files
+}
+
+// LoadSidecars override the options with the sidecar files of the input
+func (o *Options) LoadSidecars() ([]string, error) {
+	files := o.SidecarFiles()
+	for _, file := range files {
+		if err := o.decodeFile(file, file); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
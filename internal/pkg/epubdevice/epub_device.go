@@ -0,0 +1,222 @@
+/*
+Package epubdevice detect mounted e-readers and deliver EPUB files to them.
+
+A device is recognized by its marker files:
+  - Kindle: system/version.txt, books are copied into documents/
+  - Kobo: .kobo/, books are copied into a sub-folder named after the series
+
+The profile is known for the Kobo from its product id. The Kindle doesn't expose its model on the storage,
+so it has no profile.
+*/
+package epubdevice
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+type Device struct {
+	Family  string
+	Root    string
+	Profile string
+}
+
+const (
+	Kindle = "Kindle"
+	Kobo   = "Kobo"
+)
+
+// Kobo product id (last part of .kobo/version) to profile code.
+var koboProfiles = map[string]string{
+	"310": "KoMT",
+	"320": "KoMT",
+	"330": "KoG",
+	"340": "KoMT",
+	"350": "KoAHD",
+	"360": "KoA",
+	"370": "KoAH2O",
+	"371": "KoGHD",
+	"372": "KoMT",
+	"373": "KoAO",
+	"374": "KoAH2O",
+	"375": "KoA",
+	"376": "KoC",
+	"377": "KoF",
+	"380": "KoF",
+	"381": "KoAO",
+	"382": "KoN",
+	"383": "KoS",
+	"384": "KoL",
+	"386": "KoC",
+	"387": "KoE",
+	"388": "KoL",
+}
+
+func (d Device) String() string {
+	s := d.Family + " (" + d.Root + ")"
+	if d.Profile != "" {
+		s += " - profile " + d.Profile
+	}
+	return s
+}
+
+// mountPoints list the directories where removable storage are usually mounted.
+func mountPoints() []string {
+	var roots []string
+	switch runtime.GOOS {
+	case "darwin":
+		roots = append(roots, "/Volumes")
+	case "windows":
+		for c := 'D'; c <= 'Z'; c++ {
+			roots = append(roots, string(c)+":\\")
+		}
+		return roots
+	default:
+		user := os.Getenv("USER")
+		if user != "" {
+			roots = append(roots, filepath.Join("/media", user), filepath.Join("/run/media", user))
+		}
+		roots = append(roots, "/media", "/mnt")
+	}
+
+	var mounts []string
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				mounts = append(mounts, filepath.Join(root, entry.Name()))
+			}
+		}
+	}
+	return mounts
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// detect the device mounted on root
+func detect(root string) (Device, bool) {
+	if exists(filepath.Join(root, "system", "version.txt")) {
+		return Device{Family: Kindle, Root: root}, true
+	}
+	if exists(filepath.Join(root, ".kobo")) {
+		d := Device{Family: Kobo, Root: root}
+		if b, err := os.ReadFile(filepath.Join(root, ".kobo", "version")); err == nil {
+			fields := strings.Split(strings.TrimSpace(string(b)), ",")
+			productId := fields[len(fields)-1]
+			if i := strings.LastIndex(productId, "-"); i >= 0 {
+				productId = strings.TrimLeft(productId[i+1:], "0")
+			}
+			d.Profile = koboProfiles[productId]
+		}
+		return d, true
+	}
+	return Device{}, false
+}
+
+// Detect lookup for mounted e-readers.
+func Detect() []Device {
+	devices := make([]Device, 0)
+	seen := map[string]bool{}
+	for _, mount := range mountPoints() {
+		if seen[mount] {
+			continue
+		}
+		seen[mount] = true
+		if d, ok := detect(mount); ok {
+			devices = append(devices, d)
+		}
+	}
+	return devices
+}
+
+// Dir target directory of the books on the device.
+func (d Device) Dir(series string) string {
+	if d.Family == Kindle {
+		return filepath.Join(d.Root, "documents")
+	}
+	if series == "" {
+		return d.Root
+	}
+	return filepath.Join(d.Root, SanitizeName(series))
+}
+
+// SanitizeName remove characters not allowed on FAT32 storage.
+func SanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	return strings.TrimRight(strings.TrimSpace(name), ".")
+}
+
+var ErrNotEnoughSpace = errors.New("not enough free space on the device")
+
+// Deliver copy files to the device.
+//
+// It returns ErrNotEnoughSpace without copying anything if the files do not fit.
+func (d Device) Deliver(files []string, series string) ([]string, error) {
+	var total uint64
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return nil, err
+		}
+		total += uint64(fi.Size())
+	}
+
+	if free, ok := freeSpace(d.Root); ok && free < total {
+		return nil, fmt.Errorf("%w: need %d Mb, available %d Mb", ErrNotEnoughSpace, total/1024/1024, free/1024/1024)
+	}
+
+	dir := d.Dir(series)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	copied := make([]string, 0, len(files))
+	for _, f := range files {
+		dst := filepath.Join(dir, filepath.Base(f))
+		if err := copyFile(f, dst); err != nil {
+			return copied, err
+		}
+		copied = append(copied, dst)
+	}
+	return copied, nil
+}
+
+func copyFile(src, dst string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func(r *os.File) {
+		_ = r.Close()
+	}(r)
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err = w.Sync(); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
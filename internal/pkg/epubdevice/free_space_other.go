@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package epubdevice
+
+// freeSpace is not supported on this platform, the copy is done without check.
+func freeSpace(_ string) (uint64, bool) {
+	return 0, false
+}
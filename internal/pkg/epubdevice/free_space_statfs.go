@@ -0,0 +1,14 @@
+//go:build linux || darwin
+
+package epubdevice
+
+import "syscall"
+
+// freeSpace available for the current user on the filesystem of path.
+func freeSpace(path string) (uint64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), true
+}
@@ -0,0 +1,200 @@
+// Package epubevent emit the events of the conversion in json.
+//
+// Each event is a json line: {"version": 1, "type": "...", "data": {...}}
+// The events are written to stdout in json mode, and/or to a NDJSON file.
+// Without output, the events are discarded.
+package epubevent
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Version of the event schema, increased on incompatible changes.
+const Version = 1
+
+// Types of event
+const (
+	TypeOptions  = "options"
+	TypeProgress = "epubprogress"
+	TypeImage    = "image"
+	TypePart     = "part"
+	TypeWarning  = "warning"
+	TypeDevice   = "device"
+	TypeStats    = "stats"
+	TypeResult   = "result"
+	TypeSkipped  = "skipped"
+	TypeInspect  = "inspect"
+	TypeProfiles = "profiles"
+	TypeConfig   = "config"
+	TypeVerify   = "verify"
+	TypeEstimate = "estimate"
+)
+
+// Status of an image event
+const (
+	ImageLoaded       = "loaded"
+	ImageProcessed    = "processed"
+	ImageSkippedBlank = "skipped-blank"
+	ImageSplit        = "split"
+	ImageError        = "error"
+)
+
+type Event struct {
+	Version int    `json:"version"`
+	Type    string `json:"type"`
+	Data    any    `json:"data"`
+}
+
+type Image struct {
+	Status string `json:"status"`
+	Id     int    `json:"id"`
+	// part of a split double page: 1 or 2
+	Part   int    `json:"part,omitempty"`
+	Path   string `json:"path"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Size   uint64 `json:"size,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Part struct {
+	Part      int    `json:"part"`
+	Parts     int    `json:"parts"`
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	FirstPage int    `json:"first_page"`
+	LastPage  int    `json:"last_page"`
+}
+
+// Estimate of the dry run, approximate if only a sample of the pages is processed.
+type Estimate struct {
+	Sample      int            `json:"sample"`
+	Approximate bool           `json:"approximate"`
+	Size        uint64         `json:"size"`
+	Parts       []EstimatePart `json:"parts"`
+}
+
+type EstimatePart struct {
+	Part      int    `json:"part"`
+	Size      uint64 `json:"size"`
+	Pages     int    `json:"pages"`
+	FirstPage string `json:"first_page"`
+	LastPage  string `json:"last_page"`
+}
+
+type Warning struct {
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+}
+
+type Result struct {
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+	Parts    []Part `json:"parts"`
+	Pages    int    `json:"pages"`
+	Duration int64  `json:"duration_ms"`
+}
+
+type emitter struct {
+	mu      sync.Mutex
+	writers []io.Writer
+	file    *os.File
+	startAt time.Time
+	parts   []Part
+}
+
+var e = &emitter{startAt: time.Now()}
+
+// Open the outputs of the events: stdout in json mode, and the NDJSON file if set.
+func Open(stdout bool, path string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if stdout {
+		e.writers = append(e.writers, os.Stdout)
+	}
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		e.file = f
+		e.writers = append(e.writers, f)
+	}
+	return nil
+}
+
+// Close the NDJSON file
+func Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.writers = nil
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file = nil
+	return err
+}
+
+// Enabled check if the events are written somewhere
+func Enabled() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.writers) > 0
+}
+
+// Emit an event to all outputs
+func Emit(kind string, data any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if part, ok := data.(Part); ok {
+		e.parts = append(e.parts, part)
+	}
+	if len(e.writers) == 0 {
+		return
+	}
+	b, err := json.Marshal(Event{Version, kind, data})
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+	for _, w := range e.writers {
+		_, _ = w.Write(b)
+	}
+}
+
+// EmitImage emit an image event
+func EmitImage(img Image) {
+	Emit(TypeImage, img)
+}
+
+// EmitWarning emit a warning
+func EmitWarning(message string, path string) {
+	Emit(TypeWarning, Warning{message, path})
+}
+
+// EmitResult emit the final result, with the parts emitted before.
+func EmitResult(err error) {
+	e.mu.Lock()
+	r := Result{
+		Success:  err == nil,
+		Parts:    append([]Part{}, e.parts...),
+		Duration: time.Since(e.startAt).Milliseconds(),
+	}
+	e.mu.Unlock()
+	for _, p := range r.Parts {
+		r.Pages += p.LastPage - p.FirstPage + 1
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	Emit(TypeResult, r)
+}
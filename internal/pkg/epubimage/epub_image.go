@@ -5,6 +5,7 @@ import (
 	"image"
 	"strings"
 
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimagefilters"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
 )
 
@@ -22,6 +23,23 @@ type EPUBImage struct {
 	Format              string
 	OriginalAspectRatio float64
 	Error               error
+	// Size compressed size of the image, set in dry run to estimate the size of the EPUB.
+	Size uint64
+	// Preview of the transformation, set in preview mode.
+	Preview *Preview
+	// Edges left and right of the source, set to detect the spreads scanned as two pages.
+	Edges [2]epubimagefilters.Edge
+	// Spread first page of a spread scanned as two pages, it faces the next one.
+	Spread bool
+	// Facing page merged with this one into a landscape image, set with the merge of the spreads.
+	Facing *EPUBImage
+}
+
+type Preview struct {
+	Source    image.Image     // thumbnail of the source
+	Processed image.Image     // thumbnail of the result
+	Bounds    image.Rectangle // bounds of the source
+	Crop      image.Rectangle // area of the source kept by the crop
 }
 
 // SpaceKey key name of the blank page after the image
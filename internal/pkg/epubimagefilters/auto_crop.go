@@ -7,17 +7,67 @@ import (
 	"github.com/disintegration/gift"
 )
 
+// BorderColor color of the margins, compared in luminance
+type BorderColor struct {
+	// Auto detect the dominant color of each edge
+	Auto bool
+	// Y luminance of the margins if not auto
+	Y uint8
+	// Tolerance maximum difference of luminance with the margins
+	Tolerance int
+}
+
 // AutoCrop Lookup for margin and crop
-func AutoCrop(img image.Image, bounds image.Rectangle, cutRatioLeft, cutRatioUp, cutRatioRight, cutRatioBottom int, limit int, skipIfLimitReached bool) gift.Filter {
+func AutoCrop(img image.Image, bounds image.Rectangle, cutRatioLeft, cutRatioUp, cutRatioRight, cutRatioBottom int, limit int, skipIfLimitReached bool, border BorderColor) gift.Filter {
 	return gift.Crop(
-		findMargin(img, bounds, cutRatioOptions{cutRatioLeft, cutRatioUp, cutRatioRight, cutRatioBottom}, limit, skipIfLimitReached),
+		AutoCropArea(img, bounds, cutRatioLeft, cutRatioUp, cutRatioRight, cutRatioBottom, limit, skipIfLimitReached, border),
 	)
 }
 
-// check if the color is blank enough
-func colorIsBlank(c color.Color) bool {
-	g := color.GrayModel.Convert(c).(color.Gray)
-	return g.Y >= 0xe0
+// AutoCropArea Lookup for margin and return the area to keep
+func AutoCropArea(img image.Image, bounds image.Rectangle, cutRatioLeft, cutRatioUp, cutRatioRight, cutRatioBottom int, limit int, skipIfLimitReached bool, border BorderColor) image.Rectangle {
+	return findMargin(img, bounds, cutRatioOptions{cutRatioLeft, cutRatioUp, cutRatioRight, cutRatioBottom}, limit, skipIfLimitReached, border)
+}
+
+func luminance(c color.Color) int {
+	return int(color.GrayModel.Convert(c).(color.Gray).Y)
+}
+
+// check if the color is close enough to the luminance of the margin
+func colorIsBlank(c color.Color, y int, tolerance int) bool {
+	d := luminance(c) - y
+	return d >= -tolerance && d <= tolerance
+}
+
+// luminance of the margin on the line: the fixed one, or the one matching the most pixels of the line if auto
+func (b BorderColor) edge(img image.Image, line image.Rectangle) int {
+	if !b.Auto {
+		return int(b.Y)
+	}
+	return b.dominant(img, line)
+}
+
+// luminance matching the most pixels of the areas, within the tolerance
+func (b BorderColor) dominant(img image.Image, areas ...image.Rectangle) int {
+	var hist [256]int
+	for _, area := range areas {
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			for x := area.Min.X; x < area.Max.X; x++ {
+				hist[luminance(img.At(x, y))]++
+			}
+		}
+	}
+	best, bestCount := 0xff, 0
+	for v := range hist {
+		count := 0
+		for w := max(0, v-b.Tolerance); w <= min(0xff, v+b.Tolerance); w++ {
+			count += hist[w]
+		}
+		if count > bestCount {
+			best, bestCount = v, count
+		}
+	}
+	return best
 }
 
 // lookup for margin (blank) around the image
@@ -25,14 +75,15 @@ type cutRatioOptions struct {
 	Left, Up, Right, Bottom int
 }
 
-func findMargin(img image.Image, bounds image.Rectangle, cutRatio cutRatioOptions, limit int, skipIfLimitReached bool) image.Rectangle {
+func findMargin(img image.Image, bounds image.Rectangle, cutRatio cutRatioOptions, limit int, skipIfLimitReached bool, border BorderColor) image.Rectangle {
 	imgArea := bounds
 
+	blank := border.edge(img, image.Rect(imgArea.Min.X, imgArea.Min.Y, imgArea.Min.X+1, imgArea.Max.Y))
 LEFT:
 	for x := imgArea.Min.X; x < imgArea.Max.X; x++ {
 		allowNonBlank := imgArea.Dy() * cutRatio.Left / 100
 		for y := imgArea.Min.Y; y < imgArea.Max.Y; y++ {
-			if !colorIsBlank(img.At(x, y)) {
+			if !colorIsBlank(img.At(x, y), blank, border.Tolerance) {
 				allowNonBlank--
 				if allowNonBlank <= 0 {
 					break LEFT
@@ -42,11 +93,12 @@ LEFT:
 		imgArea.Min.X++
 	}
 
+	blank = border.edge(img, image.Rect(imgArea.Min.X, imgArea.Min.Y, imgArea.Max.X, imgArea.Min.Y+1))
 UP:
 	for y := imgArea.Min.Y; y < imgArea.Max.Y; y++ {
 		allowNonBlank := imgArea.Dx() * cutRatio.Up / 100
 		for x := imgArea.Min.X; x < imgArea.Max.X; x++ {
-			if !colorIsBlank(img.At(x, y)) {
+			if !colorIsBlank(img.At(x, y), blank, border.Tolerance) {
 				allowNonBlank--
 				if allowNonBlank <= 0 {
 					break UP
@@ -56,11 +108,12 @@ UP:
 		imgArea.Min.Y++
 	}
 
+	blank = border.edge(img, image.Rect(imgArea.Max.X-1, imgArea.Min.Y, imgArea.Max.X, imgArea.Max.Y))
 RIGHT:
 	for x := imgArea.Max.X - 1; x >= imgArea.Min.X; x-- {
 		allowNonBlank := imgArea.Dy() * cutRatio.Right / 100
 		for y := imgArea.Min.Y; y < imgArea.Max.Y; y++ {
-			if !colorIsBlank(img.At(x, y)) {
+			if !colorIsBlank(img.At(x, y), blank, border.Tolerance) {
 				allowNonBlank--
 				if allowNonBlank <= 0 {
 					break RIGHT
@@ -70,11 +123,12 @@ RIGHT:
 		imgArea.Max.X--
 	}
 
+	blank = border.edge(img, image.Rect(imgArea.Min.X, imgArea.Max.Y-1, imgArea.Max.X, imgArea.Max.Y))
 BOTTOM:
 	for y := imgArea.Max.Y - 1; y >= imgArea.Min.Y; y-- {
 		allowNonBlank := imgArea.Dx() * cutRatio.Bottom / 100
 		for x := imgArea.Min.X; x < imgArea.Max.X; x++ {
-			if !colorIsBlank(img.At(x, y)) {
+			if !colorIsBlank(img.At(x, y), blank, border.Tolerance) {
 				allowNonBlank--
 				if allowNonBlank <= 0 {
 					break BOTTOM
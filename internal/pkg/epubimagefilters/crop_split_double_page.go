@@ -7,27 +7,57 @@ import (
 	"github.com/disintegration/gift"
 )
 
+// Gutter of a double page: the left part ends at Left, the right part starts at Right.
+//
+// The positions are relative to the left of the page.
+type Gutter struct {
+	Left, Right int
+}
+
+// Split position of the cut: in the middle of the gutter, or at its edges to trim it.
+// Each part keeps an overlap of the other part.
+func (g Gutter) Split(trim bool, overlap int) Gutter {
+	if !trim {
+		g.Left = (g.Left + g.Right) / 2
+		g.Right = g.Left
+	}
+	g.Left += overlap
+	g.Right -= overlap
+	return g
+}
+
 // CropSplitDoublePage Cut a double page in 2 part: left and right.
 //
 // This will cut in the middle of the page.
 func CropSplitDoublePage(right bool) gift.Filter {
-	return cropSplitDoublePage{right}
+	return cropSplitDoublePage{right: right}
+}
+
+// CropSplitDoublePageAt Cut a double page in 2 part at the gutter.
+func CropSplitDoublePageAt(right bool, gutter Gutter) gift.Filter {
+	return cropSplitDoublePage{right, &gutter}
 }
 
 type cropSplitDoublePage struct {
-	right bool
+	right  bool
+	gutter *Gutter
 }
 
 func (p cropSplitDoublePage) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	left, right := srcBounds.Max.X/2, srcBounds.Max.X/2
+	if p.gutter != nil {
+		left = min(max(srcBounds.Min.X+p.gutter.Left, srcBounds.Min.X+1), srcBounds.Max.X)
+		right = max(min(srcBounds.Min.X+p.gutter.Right, srcBounds.Max.X-1), srcBounds.Min.X)
+	}
 	if p.right {
 		dstBounds = image.Rect(
-			srcBounds.Max.X/2, srcBounds.Min.Y,
+			right, srcBounds.Min.Y,
 			srcBounds.Max.X, srcBounds.Max.Y,
 		)
 	} else {
 		dstBounds = image.Rect(
 			srcBounds.Min.X, srcBounds.Min.Y,
-			srcBounds.Max.X/2, srcBounds.Max.Y,
+			left, srcBounds.Max.Y,
 		)
 	}
 	return
@@ -36,3 +66,62 @@ func (p cropSplitDoublePage) Bounds(srcBounds image.Rectangle) (dstBounds image.
 func (p cropSplitDoublePage) Draw(dst draw.Image, src image.Image, options *gift.Options) {
 	gift.Crop(dst.Bounds()).Draw(dst, src, options)
 }
+
+// FindGutter lookup for the gutter of a double page: a band with almost no ink near the middle.
+//
+// A thin shadow of the binding inside the band is allowed. Without gutter, the art cross the middle.
+func FindGutter(img image.Image, bounds image.Rectangle, border BorderColor) (Gutter, bool) {
+	w, h := bounds.Dx(), bounds.Dy()
+	blank := border.frame(img, bounds)
+	// columns with less than 1% of ink
+	maxInk := max(1, h/100)
+	// shadow of the binding allowed inside the gutter
+	maxShadow := max(1, w/100)
+	minWidth := max(2, w/200)
+
+	from, to := bounds.Min.X+w*35/100, bounds.Min.X+w*65/100
+	low := make([]bool, to-from)
+	for x := from; x < to; x++ {
+		ink := 0
+		for y := bounds.Min.Y; y < bounds.Max.Y && ink <= maxInk; y++ {
+			if !colorIsBlank(img.At(x, y), blank, border.Tolerance) {
+				ink++
+			}
+		}
+		low[x-from] = ink <= maxInk
+	}
+
+	best, found := Gutter{}, false
+	middle := w / 2
+	for start := 0; start < len(low); {
+		if !low[start] {
+			start++
+			continue
+		}
+		// extend the band over the low columns and the shadows between them
+		end := start
+		for i := start; i < len(low); i++ {
+			if low[i] {
+				end = i + 1
+			} else if i-end >= maxShadow {
+				break
+			}
+		}
+		g := Gutter{from - bounds.Min.X + start, from - bounds.Min.X + end}
+		if g.Right-g.Left >= minWidth {
+			if !found || g.Right-g.Left > best.Right-best.Left ||
+				(g.Right-g.Left == best.Right-best.Left && abs((g.Left+g.Right)/2-middle) < abs((best.Left+best.Right)/2-middle)) {
+				best, found = g, true
+			}
+		}
+		start = end
+	}
+	return best, found
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
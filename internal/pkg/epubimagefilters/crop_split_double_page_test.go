@@ -0,0 +1,42 @@
+package epubimagefilters
+
+import (
+	"fmt"
+	"image"
+)
+
+func ExampleFindGutter() {
+	for _, tc := range []struct {
+		name  string
+		rects []image.Rectangle
+	}{
+		{"gutter", []image.Rectangle{image.Rect(20, 20, 190, 280), image.Rect(210, 20, 380, 280)}},
+		{"off center", []image.Rectangle{image.Rect(20, 20, 160, 280), image.Rect(180, 20, 380, 280)}},
+		{"binding shadow", []image.Rectangle{image.Rect(20, 20, 190, 280), image.Rect(200, 0, 201, 300), image.Rect(210, 20, 380, 280)}},
+		{"art across", []image.Rectangle{image.Rect(20, 20, 380, 280)}},
+		{"too thin", []image.Rectangle{image.Rect(20, 20, 199, 280), image.Rect(200, 20, 380, 280)}},
+		{"out of the middle", []image.Rectangle{image.Rect(20, 20, 100, 280), image.Rect(120, 20, 380, 280)}},
+	} {
+		img := page(400, 300, tc.rects...)
+		g, ok := FindGutter(img, img.Bounds(), BorderColor{Y: 255, Tolerance: 16})
+		fmt.Println(tc.name, ok, g.Left, g.Right)
+	}
+	// Output: gutter true 190 210
+	// off center true 160 180
+	// binding shadow true 190 210
+	// art across false 0 0
+	// too thin false 0 0
+	// out of the middle false 0 0
+}
+
+func ExampleGutter_Split() {
+	g := Gutter{Left: 190, Right: 210}
+	fmt.Println(g.Split(false, 0))
+	fmt.Println(g.Split(true, 0))
+	fmt.Println(g.Split(false, 5))
+	fmt.Println(g.Split(true, 5))
+	// Output: {200 200}
+	// {190 210}
+	// {205 195}
+	// {195 205}
+}
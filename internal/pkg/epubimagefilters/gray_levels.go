@@ -0,0 +1,20 @@
+package epubimagefilters
+
+import (
+	"math"
+
+	"github.com/disintegration/gift"
+)
+
+// GrayLevels Reduce the image to the levels of gray of the device.
+//
+// The levels are evenly spread from black to white, so the device doesn't dither the image itself.
+func GrayLevels(levels int) gift.Filter {
+	steps := float32(levels - 1)
+	quantize := func(v float32) float32 {
+		return float32(math.Round(float64(v*steps))) / steps
+	}
+	return gift.ColorFunc(func(r0, g0, b0, a0 float32) (r float32, g float32, b float32, a float32) {
+		return quantize(r0), quantize(g0), quantize(b0), a0
+	})
+}
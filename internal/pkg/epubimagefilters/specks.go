@@ -0,0 +1,204 @@
+package epubimagefilters
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+
+	"github.com/disintegration/gift"
+)
+
+// Specks small marks isolated from the content near the edges, like page numbers and scan dust
+type Specks struct {
+	Rects []image.Rectangle
+	// Color of the margins around them
+	Color color.Gray
+}
+
+// FindSpecks lookup for the groups of marks smaller than maxArea (per thousand of the page),
+// separated from the content by at least distance (percentage of the page).
+//
+// The marks are the connected components of the pixels different from the margins.
+// The components closer than distance are grouped, the big groups are the content.
+func FindSpecks(img image.Image, bounds image.Rectangle, border BorderColor, maxArea int, distance int) Specks {
+	blank := border.frame(img, bounds)
+	s := Specks{Color: color.Gray{Y: uint8(blank)}}
+	w, h := bounds.Dx(), bounds.Dy()
+	if maxArea <= 0 || w == 0 || h == 0 {
+		return s
+	}
+
+	components := findComponents(img, bounds, blank, border.Tolerance)
+	groups := groupComponents(components, max(1, min(w, h)*distance/100))
+
+	// the content is made of the big groups
+	limit := w * h * maxArea / 1000
+	content := image.Rectangle{}
+	for _, g := range groups {
+		if g.area > limit {
+			content = content.Union(g.rect)
+		}
+	}
+	if content.Empty() {
+		return s
+	}
+	for _, g := range groups {
+		if g.area <= limit && !g.rect.Overlaps(content) {
+			s.Rects = append(s.Rects, g.rect)
+		}
+	}
+	return s
+}
+
+// component pixels connected to each other, or group of components
+type component struct {
+	area int
+	rect image.Rectangle
+}
+
+// findComponents label the 8-connected components of the pixels different from the margins.
+func findComponents(img image.Image, bounds image.Rectangle, blank int, tolerance int) []component {
+	w, h := bounds.Dx(), bounds.Dy()
+	ink := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			ink[y*w+x] = !colorIsBlank(img.At(bounds.Min.X+x, bounds.Min.Y+y), blank, tolerance)
+		}
+	}
+
+	components := make([]component, 0)
+	stack := make([]int, 0)
+	for start := range ink {
+		if !ink[start] {
+			continue
+		}
+		c := component{}
+		ink[start] = false
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			p := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			px, py := p%w, p/w
+			c.area++
+			c.rect = c.rect.Union(image.Rect(px, py, px+1, py+1))
+			for ny := max(0, py-1); ny <= min(h-1, py+1); ny++ {
+				for nx := max(0, px-1); nx <= min(w-1, px+1); nx++ {
+					if n := ny*w + nx; ink[n] {
+						ink[n] = false
+						stack = append(stack, n)
+					}
+				}
+			}
+		}
+		c.rect = c.rect.Add(bounds.Min)
+		components = append(components, c)
+	}
+	return components
+}
+
+// groupComponents group the components less than distance away from each other, or from a group.
+//
+// The components are swept from left to right, only the groups not too far on the left are compared.
+func groupComponents(components []component, distance int) []component {
+	slices.SortFunc(components, func(a, b component) int {
+		return a.rect.Min.X - b.rect.Min.X
+	})
+
+	near := func(a, b image.Rectangle) bool {
+		dx := max(0, a.Min.X-b.Max.X, b.Min.X-a.Max.X)
+		dy := max(0, a.Min.Y-b.Max.Y, b.Min.Y-a.Max.Y)
+		return dx < distance && dy < distance
+	}
+
+	groups := make([]component, 0)
+	active := make([]int, 0)
+	for _, c := range components {
+		merged := -1
+		kept := active[:0]
+		for _, g := range active {
+			switch {
+			case groups[g].rect.Max.X+distance <= c.rect.Min.X:
+				// too far on the left for this component and the next ones
+			case !near(groups[g].rect, c.rect):
+				kept = append(kept, g)
+			case merged < 0:
+				merged = g
+				groups[g].area += c.area
+				groups[g].rect = groups[g].rect.Union(c.rect)
+				kept = append(kept, g)
+			default:
+				// the component join 2 groups
+				groups[merged].area += groups[g].area
+				groups[merged].rect = groups[merged].rect.Union(groups[g].rect)
+				groups[g].area = 0
+			}
+		}
+		active = kept
+		if merged < 0 {
+			active = append(active, len(groups))
+			groups = append(groups, c)
+		}
+	}
+
+	return slices.DeleteFunc(groups, func(g component) bool {
+		return g.area == 0
+	})
+}
+
+// luminance of the margins around the page
+func (b BorderColor) frame(img image.Image, bounds image.Rectangle) int {
+	if !b.Auto {
+		return int(b.Y)
+	}
+	return b.dominant(
+		img,
+		image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Min.Y+1),
+		image.Rect(bounds.Min.X, bounds.Max.Y-1, bounds.Max.X, bounds.Max.Y),
+		image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+1, bounds.Max.Y),
+		image.Rect(bounds.Max.X-1, bounds.Min.Y, bounds.Max.X, bounds.Max.Y),
+	)
+}
+
+// Ignore the specks: they are read as the color of the margins
+func (s Specks) Ignore(img image.Image) image.Image {
+	if len(s.Rects) == 0 {
+		return img
+	}
+	return ignoreSpecks{img, s}
+}
+
+type ignoreSpecks struct {
+	image.Image
+	specks Specks
+}
+
+func (i ignoreSpecks) At(x, y int) color.Color {
+	p := image.Pt(x, y)
+	for _, r := range i.specks.Rects {
+		if p.In(r) {
+			return i.specks.Color
+		}
+	}
+	return i.Image.At(x, y)
+}
+
+// Erase the specks with the color of the margins
+func (s Specks) Erase() gift.Filter {
+	return eraseSpecks{s}
+}
+
+type eraseSpecks struct {
+	specks Specks
+}
+
+func (e eraseSpecks) Bounds(srcBounds image.Rectangle) image.Rectangle {
+	return srcBounds
+}
+
+func (e eraseSpecks) Draw(dst draw.Image, src image.Image, _ *gift.Options) {
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	for _, r := range e.specks.Rects {
+		draw.Draw(dst, r.Sub(src.Bounds().Min).Add(dst.Bounds().Min), image.NewUniform(e.specks.Color), image.Point{}, draw.Src)
+	}
+}
@@ -0,0 +1,51 @@
+package epubimagefilters
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// white page with the rectangles in black
+func page(w, h int, rects ...image.Rectangle) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for _, r := range rects {
+		draw.Draw(img, r, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func ExampleFindSpecks() {
+	content := image.Rect(40, 40, 160, 260)
+	for _, tc := range []struct {
+		name  string
+		marks []image.Rectangle
+		area  int
+	}{
+		{"no marks", nil, 1},
+		{"dust", []image.Rectangle{image.Rect(10, 10, 12, 12)}, 1},
+		{"page number", []image.Rectangle{image.Rect(90, 285, 93, 290), image.Rect(96, 285, 99, 290)}, 1},
+		{"close to the content", []image.Rectangle{image.Rect(100, 263, 102, 265)}, 1},
+		{"too big", []image.Rectangle{image.Rect(10, 270, 60, 290)}, 1},
+		{"disabled", []image.Rectangle{image.Rect(10, 10, 12, 12)}, 0},
+	} {
+		img := page(200, 300, append([]image.Rectangle{content}, tc.marks...)...)
+		s := FindSpecks(img, img.Bounds(), BorderColor{Y: 255, Tolerance: 16}, tc.area, 2)
+		fmt.Println(tc.name, s.Rects)
+	}
+	// Output: no marks []
+	// dust [(10,10)-(12,12)]
+	// page number [(90,285)-(99,290)]
+	// close to the content []
+	// too big []
+	// disabled []
+}
+
+func ExampleSpecks_Ignore() {
+	img := page(200, 300, image.Rect(40, 40, 160, 260), image.Rect(10, 10, 12, 12))
+	s := FindSpecks(img, img.Bounds(), BorderColor{Y: 255, Tolerance: 16}, 1, 2)
+	fmt.Println(luminance(s.Ignore(img).At(11, 11)), luminance(s.Ignore(img).At(50, 50)))
+	// Output: 255 0
+}
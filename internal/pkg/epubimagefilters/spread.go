@@ -0,0 +1,88 @@
+package epubimagefilters
+
+import (
+	"image"
+)
+
+const (
+	// number of samples of an edge, along the height of the page
+	edgeSamples = 64
+	// number of columns averaged for each sample
+	edgeWidth = 3
+	// maximum average difference of luminance between two edges that continue the art
+	edgeMaxDiff = 12
+)
+
+// IsTwoPages check if a landscape image is really two pages and not a single illustration.
+//
+// A gutter is found, or the halves have the shape of a page and the outer margins are symmetric.
+func IsTwoPages(img image.Image, bounds image.Rectangle, border BorderColor) bool {
+	if _, ok := FindGutter(img, bounds, border); ok {
+		return true
+	}
+
+	w, h := bounds.Dx(), bounds.Dy()
+	// the usual pages are between 1:1.8 and 1:1.2
+	if half := float64(w) / 2 / float64(h); half < 0.55 || half > 0.85 {
+		return false
+	}
+
+	area := findMargin(img, bounds, cutRatioOptions{}, 0, false, border)
+	if area.Empty() {
+		return false
+	}
+	left, right := area.Min.X-bounds.Min.X, bounds.Max.X-area.Max.X
+	return abs(left-right) <= max(1, w/50)
+}
+
+// Edge luminance of the edge of a page along its height, to check if the art continues on the facing page.
+type Edge struct {
+	Samples [edgeSamples]uint8
+	// Ink number of samples different from the margins
+	Ink int
+}
+
+// EdgeOf the left or the right of the page.
+func EdgeOf(img image.Image, bounds image.Rectangle, right bool, border BorderColor) Edge {
+	blank := border.frame(img, bounds)
+	width := min(edgeWidth, bounds.Dx())
+	from := bounds.Min.X
+	if right {
+		from = bounds.Max.X - width
+	}
+
+	var e Edge
+	h := bounds.Dy()
+	for i := range e.Samples {
+		y0 := bounds.Min.Y + i*h/edgeSamples
+		y1 := max(y0+1, bounds.Min.Y+(i+1)*h/edgeSamples)
+		sum, count := 0, 0
+		for y := y0; y < y1 && y < bounds.Max.Y; y++ {
+			for x := from; x < from+width; x++ {
+				sum += luminance(img.At(x, y))
+				count++
+			}
+		}
+		if count > 0 {
+			e.Samples[i] = uint8(sum / count)
+		}
+		if d := int(e.Samples[i]) - blank; d < -border.Tolerance || d > border.Tolerance {
+			e.Ink++
+		}
+	}
+	return e
+}
+
+// Continue check if the facing edge continues the art: both have ink on a quarter of their height, and they look alike.
+//
+// An edge that hasn't been measured has no ink.
+func (e Edge) Continue(facing Edge) bool {
+	if e.Ink < edgeSamples/4 || facing.Ink < edgeSamples/4 {
+		return false
+	}
+	diff := 0
+	for i := range e.Samples {
+		diff += abs(int(e.Samples[i]) - int(facing.Samples[i]))
+	}
+	return diff <= edgeMaxDiff*edgeSamples
+}
@@ -0,0 +1,67 @@
+package epubimagefilters
+
+import (
+	"fmt"
+	"image"
+)
+
+func ExampleIsTwoPages() {
+	for _, tc := range []struct {
+		name  string
+		w, h  int
+		rects []image.Rectangle
+	}{
+		{"gutter", 400, 300, []image.Rectangle{image.Rect(20, 20, 190, 280), image.Rect(210, 20, 380, 280)}},
+		{"symmetric margins", 400, 300, []image.Rectangle{image.Rect(20, 20, 380, 280)}},
+		{"asymmetric margins", 400, 300, []image.Rectangle{image.Rect(10, 20, 370, 280)}},
+		{"panorama", 800, 300, []image.Rectangle{image.Rect(20, 20, 780, 280)}},
+		{"full bleed", 400, 300, []image.Rectangle{image.Rect(0, 0, 400, 300)}},
+	} {
+		img := page(tc.w, tc.h, tc.rects...)
+		fmt.Println(tc.name, IsTwoPages(img, img.Bounds(), BorderColor{Y: 255, Tolerance: 16}))
+	}
+	// Output: gutter true
+	// symmetric margins true
+	// asymmetric margins false
+	// panorama false
+	// full bleed true
+}
+
+func ExampleEdgeOf() {
+	border := BorderColor{Y: 255, Tolerance: 16}
+	art := page(200, 300, image.Rect(0, 0, 100, 300))
+	half := page(200, 300, image.Rect(0, 0, 100, 150))
+	for _, tc := range []struct {
+		name  string
+		img   image.Image
+		right bool
+	}{
+		{"art on the left", art, false},
+		{"blank on the right", art, true},
+		{"half height", half, false},
+	} {
+		e := EdgeOf(tc.img, tc.img.Bounds(), tc.right, border)
+		fmt.Println(tc.name, e.Ink, e.Samples[0], e.Samples[edgeSamples-1])
+	}
+	// Output: art on the left 64 0 0
+	// blank on the right 0 255 255
+	// half height 32 0 255
+}
+
+func ExampleEdge_Continue() {
+	border := BorderColor{Y: 255, Tolerance: 16}
+	black := page(200, 300, image.Rect(0, 0, 200, 300))
+	top := page(200, 300, image.Rect(0, 0, 200, 150))
+	white := page(200, 300)
+	edge := func(img image.Image) Edge {
+		return EdgeOf(img, img.Bounds(), false, border)
+	}
+	fmt.Println(edge(black).Continue(edge(black)))
+	fmt.Println(edge(black).Continue(edge(top)))
+	fmt.Println(edge(black).Continue(edge(white)))
+	fmt.Println(Edge{}.Continue(edge(black)))
+	// Output: true
+	// false
+	// false
+	// false
+}
@@ -53,6 +53,10 @@ func (e ePUBImagePassthrough) CoverTitleData(o epubimageprocessor.CoverTitleData
 	return epubimageprocessor.New(e.EPUBOptions).CoverTitleData(o)
 }
 
+func (e ePUBImagePassthrough) MergeSpreadData(name string, left, right image.Image) (epubzip.Image, error) {
+	return epubimageprocessor.New(e.EPUBOptions).MergeSpreadData(name, left, right)
+}
+
 var errNoImagesFound = errors.New("no images found")
 
 func New(o epuboptions.EPUBOptions) epubimageprocessor.EPUBImageProcessor {
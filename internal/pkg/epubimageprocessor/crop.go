@@ -0,0 +1,263 @@
+package epubimageprocessor
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimagefilters"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubprogress"
+)
+
+// maximum difference with the median margin, in ratio of the page size, before the page is considered as an outlier
+const outlierMargin = 0.05
+
+// margins left, up, right and bottom of a page, in ratio of its size
+type margins [4]float64
+
+func pageMargins(bounds, area image.Rectangle) margins {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	return margins{
+		float64(area.Min.X-bounds.Min.X) / w,
+		float64(area.Min.Y-bounds.Min.Y) / h,
+		float64(bounds.Max.X-area.Max.X) / w,
+		float64(bounds.Max.Y-area.Max.Y) / h,
+	}
+}
+
+// area to keep of a page
+func (m margins) area(bounds image.Rectangle) image.Rectangle {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	return image.Rect(
+		bounds.Min.X+int(m[0]*w+0.5),
+		bounds.Min.Y+int(m[1]*h+0.5),
+		bounds.Max.X-int(m[2]*w+0.5),
+		bounds.Max.Y-int(m[3]*h+0.5),
+	)
+}
+
+// globalCrop common margins of the pages, for all pages or by side of the spine
+type globalCrop struct {
+	byParity bool
+	margins  map[int]margins
+	// pages using the common margins, the others keep their own crop
+	pages map[int]bool
+	// pages on the right side of the spine
+	right map[int]bool
+}
+
+func (g globalCrop) group(id int) int {
+	if g.byParity && g.right[id] {
+		return 1
+	}
+	return 0
+}
+
+// area to keep of the page, false if the page keeps its own crop
+func (g globalCrop) area(id int, bounds image.Rectangle) (image.Rectangle, bool) {
+	if !g.pages[id] {
+		return image.Rectangle{}, false
+	}
+	return g.margins[g.group(id)].area(bounds), true
+}
+
+// page as seen by the spine
+type spinePage struct {
+	double bool
+	blank  bool
+}
+
+// measure all the pages to compute the common crop, then send them back for the processing.
+//
+// The pages are decoded once and kept in memory until all of them are measured.
+// The cover, the double pages and the blank pages are excluded,
+// as well as the outliers, like full bleed pages, which margins are too far from the median.
+func (e ePUBImageProcessor) globalCrop(imageCount int, imageInput chan task) (globalCrop, chan task) {
+	bar := epubprogress.New(epubprogress.Options{
+		Quiet:       e.Quiet,
+		Json:        e.Json,
+		Max:         imageCount,
+		Description: "Crop analysis",
+		CurrentJob:  1,
+		TotalJob:    3,
+	})
+	inputs := make([]task, 0, imageCount)
+	pages := map[int]margins{}
+	spine := map[int]spinePage{}
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for range e.WorkersRatio(50) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for input := range imageInput {
+				m, p, ok := e.measure(input)
+				mu.Lock()
+				inputs = append(inputs, input)
+				if ok {
+					pages[input.Id] = m
+				}
+				spine[input.Id] = p
+				_ = bar.Add(1)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	_ = bar.Close()
+
+	g := globalCrop{
+		byParity: e.Image.Crop.Mode == 2,
+		margins:  map[int]margins{},
+		pages:    map[int]bool{},
+		right:    e.spineRight(spine),
+	}
+	groups := map[int][]int{}
+	for id := range pages {
+		groups[g.group(id)] = append(groups[g.group(id)], id)
+	}
+	for group, ids := range groups {
+		median := percentile(pages, ids, 50)
+		inliers := make([]int, 0, len(ids))
+		for _, id := range ids {
+			if isInlier(pages[id], median) {
+				inliers = append(inliers, id)
+			}
+		}
+		if len(inliers) == 0 {
+			continue
+		}
+		g.margins[group] = percentile(pages, inliers, e.Image.Crop.Percentile)
+		for _, id := range inliers {
+			g.pages[id] = true
+		}
+	}
+
+	output := make(chan task, e.Workers)
+	go func() {
+		defer close(output)
+		for i := range inputs {
+			output <- inputs[i]
+			// release the image once processed
+			inputs[i] = task{}
+		}
+	}()
+	return g, output
+}
+
+// margins of a page, false if the page is excluded from the common crop
+func (e ePUBImageProcessor) measure(input task) (margins, spinePage, bool) {
+	if e.Image.HasCover && input.Id == 0 {
+		return margins{}, spinePage{}, false
+	}
+	if input.Image == nil {
+		return margins{}, spinePage{double: input.Width > input.Height}, false
+	}
+	bounds := input.Image.Bounds()
+	if input.Error != nil {
+		return margins{}, spinePage{double: bounds.Dx() > bounds.Dy()}, false
+	}
+	area := epubimagefilters.AutoCropArea(
+		e.findSpecks(input.Image).Ignore(input.Image),
+		bounds,
+		e.Image.Crop.Left,
+		e.Image.Crop.Up,
+		e.Image.Crop.Right,
+		e.Image.Crop.Bottom,
+		e.Image.Crop.Limit,
+		e.Image.Crop.SkipIfLimitReached,
+		e.borderColor(),
+	)
+	if area.Empty() {
+		return margins{}, spinePage{blank: true}, false
+	}
+	page := spinePage{double: bounds.Dx() > bounds.Dy() && area.Dx() > area.Dy()}
+	if bounds.Dx() > bounds.Dy() {
+		return margins{}, page, false
+	}
+	return pageMargins(bounds, area), page, true
+}
+
+// pages on the right side of the spine, with the same rules as the layout of the EPUB.
+//
+// The cover and the removed blank pages are not part of the spine,
+// and the double pages, split or not, bring the next page back to the first side.
+// The side is the one of a single part EPUB.
+func (e ePUBImageProcessor) spineRight(pages map[int]spinePage) map[int]bool {
+	firstSide := !e.Image.Manga
+	isOnTheRight := firstSide
+	if e.Image.AppleBookCompatibility {
+		isOnTheRight = !isOnTheRight
+		if e.TitlePage == 1 {
+			isOnTheRight = firstSide
+		}
+	}
+
+	ids := make([]int, 0, len(pages))
+	for id := range pages {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	right := map[int]bool{}
+	for _, id := range ids {
+		page := pages[id]
+		if (e.Image.HasCover && id == 0) || (e.Image.NoBlankImage && page.blank) {
+			continue
+		}
+		if page.double {
+			isOnTheRight = firstSide
+			continue
+		}
+		isOnTheRight = !isOnTheRight
+		right[id] = isOnTheRight
+	}
+	return right
+}
+
+func isInlier(m, median margins) bool {
+	for side := range m {
+		if m[side] < median[side]-outlierMargin || m[side] > median[side]+outlierMargin {
+			return false
+		}
+	}
+	return true
+}
+
+// percentile of each side of the margins of the pages
+func percentile(pages map[int]margins, ids []int, pct int) (m margins) {
+	values := make([]float64, len(ids))
+	for side := range m {
+		for i, id := range ids {
+			values[i] = pages[id][side]
+		}
+		slices.Sort(values)
+		m[side] = values[(len(values)-1)*pct/100]
+	}
+	return
+}
+
+// color of the margins to crop
+func (e ePUBImageProcessor) borderColor() epubimagefilters.BorderColor {
+	b := epubimagefilters.BorderColor{Auto: e.Image.Crop.Color == "auto", Tolerance: e.Image.Crop.Tolerance}
+	if !b.Auto {
+		b.Y = color.GrayModel.Convert(hexColor(e.Image.Crop.Color)).(color.Gray).Y
+	}
+	return b
+}
+
+// color of the hexadecimal format RGB
+func hexColor(s string) color.Color {
+	v, _ := strconv.ParseUint(s, 16, 12)
+	return color.RGBA{R: uint8(v>>8&0xf) * 0x11, G: uint8(v>>4&0xf) * 0x11, B: uint8(v&0xf) * 0x11, A: 0xff}
+}
+
+// small marks isolated from the content, if enabled
+func (e ePUBImageProcessor) findSpecks(img image.Image) epubimagefilters.Specks {
+	if e.Image.Crop.SpeckArea <= 0 {
+		return epubimagefilters.Specks{}
+	}
+	return epubimagefilters.FindSpecks(img, img.Bounds(), e.borderColor(), e.Image.Crop.SpeckArea, e.Image.Crop.SpeckDistance)
+}
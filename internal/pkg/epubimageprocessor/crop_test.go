@@ -0,0 +1,108 @@
+package epubimageprocessor
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/celogeek/go-comic-converter/v3/pkg/epuboptions"
+)
+
+func Example_percentile() {
+	pages := map[int]margins{
+		1: {0.10, 0.05, 0.10, 0.05},
+		2: {0.12, 0.06, 0.08, 0.05},
+		3: {0.08, 0.04, 0.12, 0.05},
+		4: {0.11, 0.05, 0.09, 0.07},
+		5: {0.09, 0.05, 0.11, 0.03},
+	}
+	ids := []int{1, 2, 3, 4, 5}
+	for _, pct := range []int{0, 10, 50, 100} {
+		fmt.Println(pct, percentile(pages, ids, pct))
+	}
+	fmt.Println("one page", percentile(pages, []int{4}, 50))
+	// Output: 0 [0.08 0.04 0.08 0.03]
+	// 10 [0.08 0.04 0.08 0.03]
+	// 50 [0.1 0.05 0.1 0.05]
+	// 100 [0.12 0.06 0.12 0.07]
+	// one page [0.11 0.05 0.09 0.07]
+}
+
+func Example_isInlier() {
+	median := margins{0.10, 0.05, 0.10, 0.05}
+	for _, tc := range []struct {
+		name string
+		m    margins
+	}{
+		{"median", median},
+		{"close", margins{0.13, 0.02, 0.14, 0.09}},
+		{"full bleed", margins{0, 0, 0, 0}},
+		{"big bottom margin", margins{0.10, 0.05, 0.10, 0.20}},
+	} {
+		fmt.Println(tc.name, isInlier(tc.m, median))
+	}
+	// Output: median true
+	// close true
+	// full bleed false
+	// big bottom margin false
+}
+
+func Example_margins() {
+	bounds := image.Rect(0, 0, 1000, 1500)
+	m := pageMargins(bounds, image.Rect(100, 75, 900, 1350))
+	fmt.Println(m)
+	fmt.Println(m.area(bounds))
+	fmt.Println(m.area(image.Rect(0, 0, 500, 750)))
+	// Output: [0.1 0.05 0.1 0.1]
+	// (100,75)-(900,1350)
+	// (50,38)-(450,675)
+}
+
+func Example_spineRight() {
+	pages := map[int]spinePage{
+		0: {},
+		1: {},
+		2: {},
+		3: {double: true},
+		4: {},
+		5: {blank: true},
+		6: {},
+	}
+	for _, tc := range []struct {
+		name  string
+		image epuboptions.Image
+	}{
+		{"comic", epuboptions.Image{HasCover: true}},
+		{"manga", epuboptions.Image{HasCover: true, Manga: true}},
+		{"no blank", epuboptions.Image{HasCover: true, NoBlankImage: true}},
+		{"no cover", epuboptions.Image{}},
+	} {
+		e := ePUBImageProcessor{EPUBOptions: epuboptions.EPUBOptions{Image: tc.image}}
+		right := e.spineRight(pages)
+		fmt.Print(tc.name, ":")
+		for id := range len(pages) {
+			side := "-"
+			if _, ok := right[id]; ok {
+				side = "L"
+				if right[id] {
+					side = "R"
+				}
+			}
+			fmt.Print(" ", side)
+		}
+		fmt.Println()
+	}
+	// Output: comic: - L R - L R L
+	// manga: - R L - R L R
+	// no blank: - L R - L - R
+	// no cover: L R L - L R L
+}
+
+func Example_globalCrop_group() {
+	right := map[int]bool{1: false, 2: true}
+	for _, byParity := range []bool{false, true} {
+		g := globalCrop{byParity: byParity, right: right}
+		fmt.Println(byParity, g.group(1), g.group(2))
+	}
+	// Output: false 0 0
+	// true 0 1
+}
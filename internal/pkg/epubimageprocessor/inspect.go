@@ -0,0 +1,50 @@
+package epubimageprocessor
+
+import (
+	"image/color"
+	"sort"
+
+	"github.com/celogeek/go-comic-converter/v3/pkg/epuboptions"
+)
+
+// ImageInfo header of a source image
+type ImageInfo struct {
+	Id     int
+	Path   string
+	Name   string
+	Format string
+	Width  int
+	Height int
+	Gray   bool
+	Size   int64
+	Error  error
+}
+
+// Inspect read the header of all images of the input, without decoding them.
+func Inspect(o epuboptions.EPUBOptions) ([]ImageInfo, error) {
+	e := ePUBImageProcessor{EPUBOptions: o, inspect: true}
+	_, input, err := e.load()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ImageInfo, 0)
+	for t := range input {
+		infos = append(infos, ImageInfo{
+			Id:     t.Id,
+			Path:   t.Path,
+			Name:   t.Name,
+			Format: t.Format,
+			Width:  t.Width,
+			Height: t.Height,
+			Gray:   t.ColorModel == color.GrayModel || t.ColorModel == color.Gray16Model,
+			Size:   t.Size,
+			Error:  t.Error,
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Id < infos[j].Id
+	})
+	return infos, nil
+}
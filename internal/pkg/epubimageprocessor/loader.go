@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	_ "image/jpeg"
 	_ "image/png"
 	"io"
 	"io/fs"
@@ -38,6 +37,13 @@ type task struct {
 	Path  string
 	Name  string
 	Error error
+
+	// header of the image, set in inspect mode and for the images out of the dry run sample
+	Format     string
+	Width      int
+	Height     int
+	ColorModel color.Model
+	Size       int64
 }
 
 var errNoImagesFound = errors.New("no images found")
@@ -55,6 +61,10 @@ func (e ePUBImageProcessor) isSupportedImage(path string) bool {
 
 // load images from input
 func (e ePUBImageProcessor) load() (totalImages int, output chan task, err error) {
+	if len(e.Inputs) > 1 {
+		return e.loadInputs()
+	}
+
 	fi, err := os.Stat(e.Input)
 	if err != nil {
 		return
@@ -78,6 +88,55 @@ func (e ePUBImageProcessor) load() (totalImages int, output chan task, err error
 	}
 }
 
+// load the sources of an omnibus one after the other.
+//
+// Each source is sorted independently and become a top level directory, the ids continue across the sources.
+// The images are counted first, then a source is only read once the previous one is done.
+func (e ePUBImageProcessor) loadInputs() (totalImages int, output chan task, err error) {
+	sources := make([]ePUBImageProcessor, len(e.Inputs))
+	counts := make([]int, len(e.Inputs))
+	for i, source := range e.Inputs {
+		sources[i] = e
+		sources[i].Input, sources[i].Inputs = source.Path, nil
+		if counts[i], err = sources[i].count(); err != nil {
+			err = fmt.Errorf("%s: %w", source.Path, err)
+			return
+		}
+		totalImages += counts[i]
+	}
+
+	output = make(chan task, e.Workers)
+	go func() {
+		defer close(output)
+		offset := 0
+		for i, source := range e.Inputs {
+			_, input, lerr := sources[i].load()
+			if lerr != nil {
+				utils.Fatalf("\nerror processing %s: %s\n", source.Path, lerr)
+			}
+			for t := range input {
+				t.Id += offset
+				t.Path = filepath.Join(source.Title, t.Path) + string(filepath.Separator)
+				output <- t
+			}
+			offset += counts[i]
+		}
+	}()
+	return
+}
+
+// count the images of the input, without reading them
+func (e ePUBImageProcessor) count() (int, error) {
+	e.Dry, e.DrySample, e.inspect = true, 0, false
+	totalImages, output, err := e.load()
+	if err != nil {
+		return 0, err
+	}
+	for range output {
+	}
+	return totalImages, nil
+}
+
 func (e ePUBImageProcessor) corruptedImage(path, name string) image.Image {
 	var w, h float64 = 1200, 1920
 	f, _ := truetype.Parse(gomonobold.TTF)
@@ -101,6 +160,62 @@ func (e ePUBImageProcessor) corruptedImage(path, name string) image.Image {
 	return g.Image()
 }
 
+// skipDecode in dry mode without sample, the images are not read.
+func (e ePUBImageProcessor) skipDecode() bool {
+	return e.Dry && !e.inspect && e.DrySample <= 0
+}
+
+// headerOnly only the header of the image is read: in inspect mode, and in dry mode for the images out of the sample.
+//
+// The dimensions are enough to know the double pages, the sample is used to estimate the size.
+func (e ePUBImageProcessor) headerOnly(id int) bool {
+	return e.inspect || (e.Dry && !e.isSampled(id))
+}
+
+// isSampled check if the image is part of the dry run sample.
+//
+// The fraction of sampled images is accumulated, so the sample match the percentage.
+// The first image is always part of the sample.
+func (e ePUBImageProcessor) isSampled(id int) bool {
+	if e.DrySample <= 0 {
+		return false
+	}
+	if e.DrySample >= 100 {
+		return true
+	}
+	return (id*e.DrySample+99)/100 != ((id+1)*e.DrySample+99)/100
+}
+
+// decode the image of the task.
+//
+// In inspect mode, and for the images out of the dry run sample, only the header is decoded with image.DecodeConfig.
+// The size of the file is taken by the loader from the archive header or the file system.
+func (e ePUBImageProcessor) decode(t *task, open func() (io.ReadCloser, error)) {
+	if e.skipDecode() {
+		return
+	}
+
+	headerOnly := e.headerOnly(t.Id)
+	f, err := open()
+	if err == nil {
+		if headerOnly {
+			var config image.Config
+			config, t.Format, err = image.DecodeConfig(f)
+			if err == nil {
+				t.Width, t.Height, t.ColorModel = config.Width, config.Height, config.ColorModel
+			}
+		} else {
+			t.Image, t.Format, err = image.Decode(f)
+		}
+		_ = f.Close()
+	}
+
+	t.Error = err
+	if err != nil && !headerOnly {
+		t.Image = e.corruptedImage(t.Path, t.Name)
+	}
+}
+
 // load a directory of images
 func (e ePUBImageProcessor) loadDir() (totalImages int, output chan task, err error) {
 	images := make([]string, 0)
@@ -150,33 +265,20 @@ func (e ePUBImageProcessor) loadDir() (totalImages int, output chan task, err er
 		go func() {
 			defer wg.Done()
 			for job := range jobs {
-				var img image.Image
-				var err error
-				if !e.Dry {
-					var f *os.File
-					f, err = os.Open(job.Path)
-					if err == nil {
-						img, _, err = image.Decode(f)
-						_ = f.Close()
-					}
-				}
-
 				p, fn := filepath.Split(job.Path)
 				if p == input {
 					p = ""
 				} else {
 					p = p[len(input)+1:]
 				}
-				if err != nil {
-					img = e.corruptedImage(p, fn)
-				}
-				output <- task{
-					Id:    job.Id,
-					Image: img,
-					Path:  p,
-					Name:  fn,
-					Error: err,
+				t := task{Id: job.Id, Path: p, Name: fn}
+				if fi, ferr := os.Stat(job.Path); ferr == nil {
+					t.Size = fi.Size()
 				}
+				e.decode(&t, func() (io.ReadCloser, error) {
+					return os.Open(job.Path)
+				})
+				output <- t
 			}
 		}()
 	}
@@ -242,28 +344,10 @@ func (e ePUBImageProcessor) loadCbz() (totalImages int, output chan task, err er
 		go func() {
 			defer wg.Done()
 			for job := range jobs {
-				var img image.Image
-				var err error
-				if !e.Dry {
-					var f io.ReadCloser
-					f, err = job.F.Open()
-					if err == nil {
-						img, _, err = image.Decode(f)
-					}
-					_ = f.Close()
-				}
-
 				p, fn := filepath.Split(filepath.Clean(job.F.Name))
-				if err != nil {
-					img = e.corruptedImage(p, fn)
-				}
-				output <- task{
-					Id:    job.Id,
-					Image: img,
-					Path:  p,
-					Name:  fn,
-					Error: err,
-				}
+				t := task{Id: job.Id, Path: p, Name: fn, Size: int64(job.F.UncompressedSize64)}
+				e.decode(&t, job.F.Open)
+				output <- t
 			}
 		}()
 	}
@@ -310,13 +394,15 @@ func (e ePUBImageProcessor) loadCbr() (totalImages int, output chan task, err er
 	type job struct {
 		Id   int
 		Name string
+		Size int64
 		Open func() (io.ReadCloser, error)
 	}
 
 	jobs := make(chan job)
 	go func() {
 		defer close(jobs)
-		if isSolid && !e.Dry {
+		// the first image is always decoded, except if nothing need to be read
+		if isSolid && !e.skipDecode() {
 			r, rerr := rardecode.OpenReader(e.Input)
 			if rerr != nil {
 				utils.Fatalf("\nerror processing image %s: %s\n", e.Input, rerr)
@@ -338,7 +424,7 @@ func (e ePUBImageProcessor) loadCbr() (totalImages int, output chan task, err er
 					if rerr != nil {
 						utils.Fatalf("\nerror processing image %s: %s\n", f.Name, rerr)
 					}
-					jobs <- job{i, f.Name, func() (io.ReadCloser, error) {
+					jobs <- job{i, f.Name, f.UnPackedSize, func() (io.ReadCloser, error) {
 						return io.NopCloser(bytes.NewReader(b.Bytes())), nil
 					}}
 				}
@@ -346,7 +432,7 @@ func (e ePUBImageProcessor) loadCbr() (totalImages int, output chan task, err er
 		} else {
 			for _, img := range files {
 				if i, ok := indexedNames[img.Name]; ok {
-					jobs <- job{i, img.Name, img.Open}
+					jobs <- job{i, img.Name, img.UnPackedSize, img.Open}
 				}
 			}
 		}
@@ -360,28 +446,13 @@ func (e ePUBImageProcessor) loadCbr() (totalImages int, output chan task, err er
 		go func() {
 			defer wg.Done()
 			for job := range jobs {
-				var img image.Image
-				var err error
-				if !e.Dry {
-					var f io.ReadCloser
-					f, err = job.Open()
-					if err == nil {
-						img, _, err = image.Decode(f)
-					}
-					_ = f.Close()
-				}
-
 				p, fn := filepath.Split(filepath.Clean(job.Name))
-				if err != nil {
-					img = e.corruptedImage(p, fn)
-				}
-				output <- task{
-					Id:    job.Id,
-					Image: img,
-					Path:  p,
-					Name:  fn,
-					Error: err,
+				t := task{Id: job.Id, Path: p, Name: fn, Size: job.Size}
+				if t.Size < 0 {
+					t.Size = 0
 				}
+				e.decode(&t, job.Open)
+				output <- t
 			}
 		}()
 	}
@@ -407,25 +478,45 @@ func (e ePUBImageProcessor) loadPdf() (totalImages int, output chan task, err er
 		defer close(output)
 		defer pdf.Close()
 		for i := range totalImages {
-			var img image.Image
-			var err error
-			if !e.Dry {
-				img, err = pdfimage.Extract(pdf, i+1)
-			}
-
-			name := fmt.Sprintf(pageFmt, i+1)
-			if err != nil {
-				img = e.corruptedImage("", name)
-			}
-			output <- task{
-				Id:    i,
-				Image: img,
-				Path:  "",
-				Name:  name,
-				Error: err,
+			t := task{Id: i, Path: "", Name: fmt.Sprintf(pageFmt, i+1)}
+			if e.skipDecode() {
+				// nothing to read
+			} else if e.headerOnly(i) {
+				t.Error = e.pdfPageHeader(pdf, &t)
+			} else {
+				t.Image, t.Error = pdfimage.Extract(pdf, i+1)
+				if t.Error != nil {
+					t.Image = e.corruptedImage("", t.Name)
+				}
 			}
+			output <- t
 		}
 	}()
 
 	return
 }
+
+// pdfPageHeader read the header of the image of a pdf page from its dictionary, without reading the stream.
+//
+// The size is the length of the stream as stored in the pdf.
+func (e ePUBImageProcessor) pdfPageHeader(pdf *pdfread.PdfReaderT, t *task) error {
+	resources := pdf.Dic(pdf.Att("/Resources", pdf.Pages()[t.Id]))
+	for _, ref := range pdf.Dic(resources["/XObject"]) {
+		dic := pdf.Dic(ref)
+		if string(dic["/Subtype"]) != "/Image" {
+			continue
+		}
+		t.Width, t.Height = pdf.Num(dic["/Width"]), pdf.Num(dic["/Height"])
+		t.Size = int64(pdf.Num(dic["/Length"]))
+		if string(pdf.Obj(dic["/ColorSpace"])) == "/DeviceGray" {
+			t.ColorModel = color.GrayModel
+		} else {
+			t.ColorModel = color.RGBAModel
+		}
+		if string(dic["/Filter"]) == "/DCTDecode" {
+			t.Format = "jpeg"
+		}
+		return nil
+	}
+	return fmt.Errorf("no image found in %s", t.Name)
+}
@@ -5,10 +5,12 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
+	"path/filepath"
 	"sync"
 
 	"github.com/disintegration/gift"
 
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubevent"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimage"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimagefilters"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubprogress"
@@ -20,14 +22,17 @@ import (
 type EPUBImageProcessor interface {
 	Load() (images []epubimage.EPUBImage, err error)
 	CoverTitleData(o CoverTitleDataOptions) (epubzip.Image, error)
+	MergeSpreadData(name string, left, right image.Image) (epubzip.Image, error)
 }
 
 type ePUBImageProcessor struct {
 	epuboptions.EPUBOptions
+	inspect bool
+	crop    globalCrop
 }
 
 func New(o epuboptions.EPUBOptions) EPUBImageProcessor {
-	return ePUBImageProcessor{o}
+	return ePUBImageProcessor{EPUBOptions: o}
 }
 
 // Load extract and convert images
@@ -38,8 +43,14 @@ func (e ePUBImageProcessor) Load() (images []epubimage.EPUBImage, err error) {
 		return nil, err
 	}
 
-	// dry run, skip conversion
-	if e.Dry {
+	job, totalJob := 1, 2
+	if e.Image.GlobalCrop() && !(e.Dry && e.DrySample <= 0) {
+		e.crop, imageInput = e.globalCrop(imageCount, imageInput)
+		job, totalJob = 2, 3
+	}
+
+	// dry run without sample, skip conversion
+	if e.Dry && e.DrySample <= 0 {
 		for img := range imageInput {
 			images = append(images, epubimage.EPUBImage{
 				Id:     img.Id,
@@ -60,15 +71,31 @@ func (e ePUBImageProcessor) Load() (images []epubimage.EPUBImage, err error) {
 		Json:        e.Json,
 		Max:         imageCount,
 		Description: "Processing",
-		CurrentJob:  1,
-		TotalJob:    2,
+		CurrentJob:  job,
+		TotalJob:    totalJob,
 	})
 	wg := &sync.WaitGroup{}
 
-	imgStorage, err := epubzip.NewStorageImageWriter(e.ImgStorage(), e.Image.Format)
-	if err != nil {
-		_ = bar.Close()
-		return nil, err
+	// in dry run, the images are only compressed to estimate their size
+	var imgStorage epubzip.StorageImageWriter
+	if !e.Dry {
+		imgStorage, err = epubzip.NewStorageImageWriter(e.ImgStorage(), e.Image.Format)
+		if err != nil {
+			_ = bar.Close()
+			return nil, err
+		}
+	}
+	store := func(img *epubimage.EPUBImage) error {
+		if !e.Dry {
+			return imgStorage.Add(img.EPUBImgPath(), img.Raw, e.Image.Quality)
+		}
+		zipImage, err := epubzip.CompressImage(img.EPUBImgPath(), e.Image.Format, img.Raw, e.Image.Quality)
+		if err != nil {
+			return err
+		}
+		img.Size = zipImage.Size()
+		img.Raw = nil
+		return nil
 	}
 
 	wr := 50
@@ -81,12 +108,40 @@ func (e ePUBImageProcessor) Load() (images []epubimage.EPUBImage, err error) {
 			defer wg.Done()
 
 			for input := range imageInput {
-				img := e.transformImage(input, 0, e.Image.Manga)
+				// not part of the dry run sample, the layout use the dimensions of the header
+				if input.Image == nil {
+					img := e.headerImage(input, 0)
+					split := e.Image.AutoSplitDoublePage && img.DoublePage && !e.Image.MergeSpread
+					if !(split && input.Id > 0 && !e.EPUBOptions.Image.KeepDoublePageIfSplit) {
+						imageOutput <- img
+					}
+					if split && !(e.Image.HasCover && img.Id == 0) {
+						imageOutput <- e.headerImage(input, 1)
+						imageOutput <- e.headerImage(input, 2)
+					}
+					continue
+				}
+
+				e.emitInput(input)
+				img := e.transformImage(input, 0, e.Image.Manga, nil)
+
+				if e.Image.DetectSpread && !img.DoublePage && !img.IsBlank && input.Error == nil {
+					img.Edges = e.edges(input)
+				}
+
+				// a single illustration, or a spread without gutter, is kept whole
+				var gutter *epubimagefilters.Gutter
+				split := e.Image.AutoSplitDoublePage && img.DoublePage && !e.Image.MergeSpread
+				if split && e.Image.DetectSpread {
+					split = epubimagefilters.IsTwoPages(input.Image, input.Image.Bounds(), e.borderColor())
+				}
+				if split {
+					gutter, split = e.findGutter(input)
+				}
 
 				// do not keep double page if requested
-				if !(img.DoublePage && input.Id > 0 &&
-					e.EPUBOptions.Image.AutoSplitDoublePage && !e.EPUBOptions.Image.KeepDoublePageIfSplit) {
-					if err = imgStorage.Add(img.EPUBImgPath(), img.Raw, e.Image.Quality); err != nil {
+				if !(split && input.Id > 0 && !e.EPUBOptions.Image.KeepDoublePageIfSplit) {
+					if err = store(&img); err != nil {
 						_ = bar.Close()
 						utils.Fatalf("error with %s: %s", input.Name, err)
 					}
@@ -94,23 +149,24 @@ func (e ePUBImageProcessor) Load() (images []epubimage.EPUBImage, err error) {
 					if img.Id > 0 {
 						img.Raw = nil
 					}
+					emitImage(epubevent.ImageProcessed, img)
 					imageOutput <- img
 				}
 
 				// DOUBLE PAGE
-				if !e.Image.AutoSplitDoublePage || // No split required
-					!img.DoublePage || // Not a double page
+				if !split || // No split required, not a double page or no gutter
 					(e.Image.HasCover && img.Id == 0) { // Cover
 					continue
 				}
 
 				for i, b := range []bool{e.Image.Manga, !e.Image.Manga} {
-					img = e.transformImage(input, i+1, b)
-					if err = imgStorage.Add(img.EPUBImgPath(), img.Raw, e.Image.Quality); err != nil {
+					img = e.transformImage(input, i+1, b, gutter)
+					if err = store(&img); err != nil {
 						_ = bar.Close()
 						utils.Fatalf("error with %s: %s", input.Name, err)
 					}
 					img.Raw = nil
+					emitImage(epubevent.ImageSplit, img)
 					imageOutput <- img
 				}
 			}
@@ -119,7 +175,9 @@ func (e ePUBImageProcessor) Load() (images []epubimage.EPUBImage, err error) {
 
 	go func() {
 		wg.Wait()
-		_ = imgStorage.Close()
+		if !e.Dry {
+			_ = imgStorage.Close()
+		}
 		close(imageOutput)
 	}()
 
@@ -127,7 +185,9 @@ func (e ePUBImageProcessor) Load() (images []epubimage.EPUBImage, err error) {
 		if img.Part == 0 {
 			_ = bar.Add(1)
 		}
-		if e.Image.NoBlankImage && img.IsBlank {
+		// blank images are kept in preview mode to be reported
+		if e.Image.NoBlankImage && img.IsBlank && !e.Preview {
+			emitImage(epubevent.ImageSkippedBlank, img)
 			continue
 		}
 		images = append(images, img)
@@ -138,9 +198,60 @@ func (e ePUBImageProcessor) Load() (images []epubimage.EPUBImage, err error) {
 		return nil, errNoImagesFound
 	}
 
+	if e.Dry {
+		e.estimateSize(images)
+	}
+
 	return images, nil
 }
 
+// emit the loading of the source image, or its error
+func (e ePUBImageProcessor) emitInput(input task) {
+	ev := epubevent.Image{
+		Status: epubevent.ImageLoaded,
+		Id:     input.Id,
+		Path:   filepath.Join(input.Path, input.Name),
+		Width:  input.Image.Bounds().Dx(),
+		Height: input.Image.Bounds().Dy(),
+	}
+	if input.Error != nil {
+		ev.Status, ev.Error = epubevent.ImageError, input.Error.Error()
+	}
+	epubevent.EmitImage(ev)
+}
+
+// emit the status of a converted image
+func emitImage(status string, img epubimage.EPUBImage) {
+	epubevent.EmitImage(epubevent.Image{
+		Status: status,
+		Id:     img.Id,
+		Part:   img.Part,
+		Path:   filepath.Join(img.Path, img.Name),
+		Width:  img.Width,
+		Height: img.Height,
+		Size:   img.Size,
+	})
+}
+
+// estimateSize set the average size of the sample to images that haven't been processed.
+func (e ePUBImageProcessor) estimateSize(images []epubimage.EPUBImage) {
+	var total, count uint64
+	for _, img := range images {
+		if img.Size > 0 {
+			total += img.Size
+			count++
+		}
+	}
+	if count == 0 {
+		return
+	}
+	for i := range images {
+		if images[i].Size == 0 {
+			images[i].Size = total / count
+		}
+	}
+}
+
 func (e ePUBImageProcessor) createImage(src image.Image, r image.Rectangle) draw.Image {
 	if e.EPUBOptions.Image.GrayScale {
 		return image.NewGray(r)
@@ -174,21 +285,28 @@ func (e ePUBImageProcessor) createImage(src image.Image, r image.Rectangle) draw
 
 // transform image into 1 or 3 images
 // only doublepage with autosplit has 3 versions
-func (e ePUBImageProcessor) transformImage(input task, part int, right bool) epubimage.EPUBImage {
+func (e ePUBImageProcessor) transformImage(input task, part int, right bool, gutter *epubimagefilters.Gutter) epubimage.EPUBImage {
 	g := gift.New()
 	src := input.Image
 	srcBounds := src.Bounds()
 
+	// small marks near the edges are ignored by the crop, and erased if requested
+	specks := e.findSpecks(src)
+	if e.Image.Crop.RemoveSpecks && len(specks.Rects) > 0 {
+		g.Add(specks.Erase())
+	}
+
 	// In portrait only, we don't need to keep aspect ratio between each split.
 	// We first cut, the crop.
 	if part > 0 && !e.Image.KeepSplitDoublePageAspect {
-		g.Add(epubimagefilters.CropSplitDoublePage(right))
+		g.Add(cropSplitDoublePage(right, gutter, 0))
 	}
 
 	// Lookup for margin if crop is enable or if we want to remove blank image
+	cropArea := g.Bounds(srcBounds)
 	if e.Image.Crop.Enabled || e.Image.NoBlankImage {
-		f := epubimagefilters.AutoCrop(
-			src,
+		area := epubimagefilters.AutoCropArea(
+			specks.Ignore(src),
 			g.Bounds(src.Bounds()),
 			e.Image.Crop.Left,
 			e.Image.Crop.Up,
@@ -196,22 +314,30 @@ func (e ePUBImageProcessor) transformImage(input task, part int, right bool) epu
 			e.Image.Crop.Bottom,
 			e.Image.Crop.Limit,
 			e.Image.Crop.SkipIfLimitReached,
+			e.borderColor(),
 		)
+		f := gift.Crop(area)
 
 		// detect if blank image
 		size := f.Bounds(srcBounds)
 		isBlank := size.Dx() == 0 && size.Dy() == 0
 
+		// common crop of the pages
+		if globalArea, ok := e.crop.area(input.Id, srcBounds); ok && part == 0 && !isBlank {
+			area, f = globalArea, gift.Crop(globalArea)
+		}
+
 		// crop is enable or if blank image with noblankimage options
 		if e.Image.Crop.Enabled || (e.Image.NoBlankImage && isBlank) {
 			g.Add(f)
+			cropArea = area
 		}
 	}
 
 	// With landscape support, we need to keep aspect ratio between each split
 	// We first crop, then cut
 	if part > 0 && e.Image.KeepSplitDoublePageAspect {
-		g.Add(epubimagefilters.CropSplitDoublePage(right))
+		g.Add(cropSplitDoublePage(right, gutter, srcBounds.Min.X-cropArea.Min.X))
 	}
 
 	dstBounds := g.Bounds(src.Bounds())
@@ -256,6 +382,10 @@ func (e ePUBImageProcessor) transformImage(input task, part int, right bool) epu
 			f = gift.Grayscale()
 		}
 		g.Add(f)
+
+		if e.Image.GrayLevels > 1 {
+			g.Add(epubimagefilters.GrayLevels(e.Image.GrayLevels))
+		}
 	}
 
 	g.Add(epubimagefilters.Pixel())
@@ -263,6 +393,22 @@ func (e ePUBImageProcessor) transformImage(input task, part int, right bool) epu
 	dst := e.createImage(src, g.Bounds(src.Bounds()))
 	g.Draw(dst, src)
 
+	var preview *epubimage.Preview
+	if e.Preview {
+		// the cut after the crop is in the coordinates of the cropped image
+		kept := cropArea
+		if part > 0 && e.Image.KeepSplitDoublePageAspect {
+			cut := cropSplitDoublePage(right, gutter, srcBounds.Min.X-cropArea.Min.X)
+			kept = cut.Bounds(cropArea.Sub(cropArea.Min)).Add(cropArea.Min)
+		}
+		preview = &epubimage.Preview{
+			Source:    e.thumbnail(src),
+			Processed: e.thumbnail(dst),
+			Bounds:    srcBounds,
+			Crop:      kept,
+		}
+	}
+
 	return epubimage.EPUBImage{
 		Id:                  input.Id,
 		Part:                part,
@@ -276,10 +422,43 @@ func (e ePUBImageProcessor) transformImage(input task, part int, right bool) epu
 		Format:              e.Image.Format,
 		OriginalAspectRatio: float64(src.Bounds().Dy()) / float64(src.Bounds().Dx()),
 		Error:               input.Error,
+		Preview:             preview,
 	}
 
 }
 
+// headerImage image out of the dry run sample, only known by its dimensions.
+//
+// The crop can't be applied, so a double page is only detected on the source dimensions.
+func (e ePUBImageProcessor) headerImage(input task, part int) epubimage.EPUBImage {
+	img := epubimage.EPUBImage{
+		Id:         input.Id,
+		Part:       part,
+		Width:      input.Width,
+		Height:     input.Height,
+		DoublePage: part == 0 && input.Width > input.Height,
+		Path:       input.Path,
+		Name:       input.Name,
+		Format:     e.Image.Format,
+		Error:      input.Error,
+	}
+	if part > 0 {
+		img.Width = (img.Width + 1) / 2
+	}
+	if input.Width > 0 {
+		img.OriginalAspectRatio = float64(input.Height) / float64(input.Width)
+	}
+	return img
+}
+
+// thumbnail reduced version of the image for the preview
+func (e ePUBImageProcessor) thumbnail(src image.Image) image.Image {
+	g := gift.New(gift.ResizeToFit(200, 300, gift.LanczosResampling))
+	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+	return dst
+}
+
 type CoverTitleDataOptions struct {
 	Src         image.Image
 	Name        string
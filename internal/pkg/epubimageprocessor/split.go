@@ -0,0 +1,43 @@
+package epubimageprocessor
+
+import (
+	"github.com/disintegration/gift"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimagefilters"
+)
+
+// position of the cut of a double page, false if the page should not be split.
+//
+// Without gutter detection, the page is cut in the middle. A spread without gutter is not split.
+func (e ePUBImageProcessor) findGutter(input task) (*epubimagefilters.Gutter, bool) {
+	if !e.Image.SplitDoublePageGutter {
+		return nil, true
+	}
+	bounds := input.Image.Bounds()
+	gutter, ok := epubimagefilters.FindGutter(input.Image, bounds, e.borderColor())
+	if !ok {
+		return nil, false
+	}
+	gutter = gutter.Split(e.Image.SplitDoublePageTrimGutter, bounds.Dx()*e.Image.SplitDoublePageOverlap/100)
+	return &gutter, true
+}
+
+// left and right edges of the page, to find the spreads scanned as two pages
+func (e ePUBImageProcessor) edges(input task) [2]epubimagefilters.Edge {
+	bounds, border := input.Image.Bounds(), e.borderColor()
+	return [2]epubimagefilters.Edge{
+		epubimagefilters.EdgeOf(input.Image, bounds, false, border),
+		epubimagefilters.EdgeOf(input.Image, bounds, true, border),
+	}
+}
+
+// cut of a double page, shifted when the page has been cropped before
+func cropSplitDoublePage(right bool, gutter *epubimagefilters.Gutter, shift int) gift.Filter {
+	if gutter == nil {
+		return epubimagefilters.CropSplitDoublePage(right)
+	}
+	g := *gutter
+	g.Left += shift
+	g.Right += shift
+	return epubimagefilters.CropSplitDoublePageAt(right, g)
+}
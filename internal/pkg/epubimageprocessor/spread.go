@@ -0,0 +1,23 @@
+package epubimageprocessor
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubzip"
+)
+
+// MergeSpreadData compose the facing pages into one landscape image.
+//
+// The pages touch each other in the middle, and are centered vertically on the background color.
+func (e ePUBImageProcessor) MergeSpreadData(name string, left, right image.Image) (epubzip.Image, error) {
+	lb, rb := left.Bounds(), right.Bounds()
+	w, h := lb.Dx()+rb.Dx(), max(lb.Dy(), rb.Dy())
+
+	dst := e.createImage(left, image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(hexColor(e.Image.View.Color.Background)), image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(0, (h-lb.Dy())/2, lb.Dx(), (h+lb.Dy())/2), left, lb.Min, draw.Src)
+	draw.Draw(dst, image.Rect(lb.Dx(), (h-rb.Dy())/2, w, (h+rb.Dy())/2), right, rb.Min, draw.Src)
+
+	return epubzip.CompressImage(name, e.Image.Format, dst, e.Image.Quality)
+}
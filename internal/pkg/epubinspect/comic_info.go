@@ -0,0 +1,100 @@
+package epubinspect
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nwaples/rardecode/v2"
+)
+
+// ComicInfo subset of the ComicInfo.xml of ComicRack
+type ComicInfo struct {
+	Title  string `xml:"Title"`
+	Series string `xml:"Series"`
+	Number string `xml:"Number"`
+	Volume string `xml:"Volume"`
+	Writer string `xml:"Writer"`
+	Manga  string `xml:"Manga"`
+}
+
+func isComicInfo(name string) bool {
+	return strings.EqualFold(filepath.Base(name), "ComicInfo.xml")
+}
+
+func decodeComicInfo(r io.Reader) (ComicInfo, bool) {
+	var c ComicInfo
+	if err := xml.NewDecoder(r).Decode(&c); err != nil {
+		return ComicInfo{}, false
+	}
+	return c, true
+}
+
+// ReadComicInfo lookup for a ComicInfo.xml in the input: directory, cbz or cbr.
+func ReadComicInfo(input string) (ComicInfo, bool) {
+	fi, err := os.Stat(input)
+	if err != nil {
+		return ComicInfo{}, false
+	}
+
+	if fi.IsDir() {
+		f, err := os.Open(filepath.Join(input, "ComicInfo.xml"))
+		if err != nil {
+			return ComicInfo{}, false
+		}
+		defer func(f *os.File) {
+			_ = f.Close()
+		}(f)
+		return decodeComicInfo(f)
+	}
+
+	switch strings.ToLower(filepath.Ext(input)) {
+	case ".cbz", ".zip":
+		r, err := zip.OpenReader(input)
+		if err != nil {
+			return ComicInfo{}, false
+		}
+		defer func(r *zip.ReadCloser) {
+			_ = r.Close()
+		}(r)
+		for _, f := range r.File {
+			if isComicInfo(f.Name) {
+				rc, err := f.Open()
+				if err != nil {
+					return ComicInfo{}, false
+				}
+				defer func(rc io.ReadCloser) {
+					_ = rc.Close()
+				}(rc)
+				return decodeComicInfo(rc)
+			}
+		}
+	case ".cbr", ".rar":
+		r, err := rardecode.OpenReader(input)
+		if err != nil {
+			return ComicInfo{}, false
+		}
+		defer func(r *rardecode.ReadCloser) {
+			_ = r.Close()
+		}(r)
+		for {
+			f, err := r.Next()
+			if err != nil {
+				break
+			}
+			if isComicInfo(f.Name) {
+				return decodeComicInfo(r)
+			}
+		}
+	}
+	return ComicInfo{}, false
+}
+
+// isManga check if ComicInfo.xml declare a right to left reading
+func isManga(input string) bool {
+	c, ok := ReadComicInfo(input)
+	return ok && c.Manga == "YesAndRightToLeft"
+}
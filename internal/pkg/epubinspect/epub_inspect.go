@@ -0,0 +1,185 @@
+// Package epubinspect analyze a source before converting it.
+//
+// Only the header of the images is read, so it is fast even on big archives.
+package epubinspect
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimageprocessor"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubtree"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
+	"github.com/celogeek/go-comic-converter/v3/pkg/epuboptions"
+)
+
+// an image is likely blank if its compressed size is very low compare to its area.
+const blankBytesPerPixel = 0.02
+
+type Dimension struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Count  int `json:"count"`
+}
+
+type Corrupted struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+type Report struct {
+	Input       string      `json:"input"`
+	Pages       int         `json:"pages"`
+	Dimensions  []Dimension `json:"dimensions"`
+	DoublePages []string    `json:"double_pages"`
+	BlankPages  []string    `json:"blank_pages"`
+	ColorPages  int         `json:"color_pages"`
+	GrayPages   int         `json:"gray_pages"`
+	Corrupted   []Corrupted `json:"corrupted"`
+	Manga       bool        `json:"manga"`
+	Tree        string      `json:"tree"`
+	Suggestions []string    `json:"suggestions"`
+}
+
+// New inspect the input of the options
+func New(o epuboptions.EPUBOptions) (Report, error) {
+	infos, err := epubimageprocessor.Inspect(o)
+	if err != nil {
+		return Report{}, err
+	}
+
+	r := Report{
+		Input:       o.Input,
+		Pages:       len(infos),
+		Dimensions:  []Dimension{},
+		DoublePages: []string{},
+		BlankPages:  []string{},
+		Corrupted:   []Corrupted{},
+		Suggestions: []string{},
+	}
+
+	dimensions := map[[2]int]int{}
+	tree := epubtree.New()
+	for _, info := range infos {
+		name := filepath.Join(info.Path, info.Name)
+		tree.Add(info.Path)
+		if info.Error != nil {
+			r.Corrupted = append(r.Corrupted, Corrupted{name, info.Error.Error()})
+			continue
+		}
+		dimensions[[2]int{info.Width, info.Height}]++
+		if info.Width > info.Height {
+			r.DoublePages = append(r.DoublePages, name)
+		}
+		// the size is unknown if the image can't be encoded
+		if info.Size > 0 && float64(info.Size) < float64(info.Width*info.Height)*blankBytesPerPixel {
+			r.BlankPages = append(r.BlankPages, name)
+		}
+		if info.Gray {
+			r.GrayPages++
+		} else {
+			r.ColorPages++
+		}
+	}
+
+	for k, v := range dimensions {
+		r.Dimensions = append(r.Dimensions, Dimension{k[0], k[1], v})
+	}
+	sort.Slice(r.Dimensions, func(i, j int) bool {
+		if r.Dimensions[i].Count == r.Dimensions[j].Count {
+			return r.Dimensions[i].Width*r.Dimensions[i].Height > r.Dimensions[j].Width*r.Dimensions[j].Height
+		}
+		return r.Dimensions[i].Count > r.Dimensions[j].Count
+	})
+
+	r.Tree = tree.Root().WriteString("")
+	r.Manga = isManga(o.Input)
+	r.Suggestions = r.suggest(o)
+
+	return r, nil
+}
+
+// suggest options from the report
+func (r Report) suggest(o epuboptions.EPUBOptions) []string {
+	s := []string{}
+	if r.Manga && !o.Image.Manga {
+		s = append(s, "-manga likely (ComicInfo.xml is right to left)")
+	}
+	if len(r.DoublePages) > 0 && !o.Image.AutoSplitDoublePage {
+		s = append(s, "-autosplitdoublepage recommended ("+utils.IntToString(len(r.DoublePages))+" double pages)")
+	}
+	if len(r.BlankPages) > 0 && !o.Image.NoBlankImage {
+		s = append(s, "-noblankimage recommended ("+utils.IntToString(len(r.BlankPages))+" likely blank pages)")
+	}
+	if r.ColorPages > 0 && o.Image.GrayScale {
+		s = append(s, "-grayscale=false if your device support color ("+utils.IntToString(r.ColorPages)+" color pages)")
+	}
+	if r.ColorPages == 0 && r.GrayPages > 0 && !o.Image.GrayScale {
+		s = append(s, "-grayscale recommended (all pages are grayscale)")
+	}
+	if len(r.Dimensions) > 1 && o.Image.View.AspectRatio == -1 {
+		s = append(s, "-aspect-ratio 0 recommended (pages have different dimensions)")
+	}
+	if len(r.Corrupted) > 0 {
+		s = append(s, utils.IntToString(len(r.Corrupted))+" corrupted images will be replaced by an error page")
+	}
+	return s
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+	b.WriteString("Inspect:\n")
+	for _, v := range []struct {
+		K string
+		V any
+	}{
+		{"Input", r.Input},
+		{"Pages", r.Pages},
+		{"Double pages", len(r.DoublePages)},
+		{"Likely blank pages", len(r.BlankPages)},
+		{"Color pages", r.ColorPages},
+		{"Grayscale pages", r.GrayPages},
+		{"Corrupted", len(r.Corrupted)},
+	} {
+		b.WriteString(fmt.Sprintf("    %-32s: %v\n", v.K, v.V))
+	}
+
+	b.WriteString("\nDimensions:\n")
+	for _, d := range r.Dimensions {
+		b.WriteString(fmt.Sprintf("    %5d x %-5d: %d\n", d.Width, d.Height, d.Count))
+	}
+
+	for _, l := range []struct {
+		Title string
+		Files []string
+	}{
+		{"Double pages", r.DoublePages},
+		{"Likely blank pages", r.BlankPages},
+	} {
+		if len(l.Files) > 0 {
+			b.WriteString("\n" + l.Title + ":\n")
+			for _, f := range l.Files {
+				b.WriteString("  - " + f + "\n")
+			}
+		}
+	}
+
+	if len(r.Corrupted) > 0 {
+		b.WriteString("\nCorrupted:\n")
+		for _, c := range r.Corrupted {
+			b.WriteString("  - " + c.Path + ": " + c.Error + "\n")
+		}
+	}
+
+	b.WriteString("\nTree:\n" + r.Tree)
+
+	if len(r.Suggestions) > 0 {
+		b.WriteString("\nSuggestions:\n")
+		for _, s := range r.Suggestions {
+			b.WriteString("  - " + s + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,217 @@
+/*
+Package epubledger record the conversions done in an output directory.
+
+The ledger is a JSON file in the output directory. For each input, it keeps the state of the sources,
+the hash of the options and the EPUB produced, so an unchanged input can be skipped on the next run.
+*/
+package epubledger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
+)
+
+// Name of the ledger file in the output directory
+const Name = ".go-comic-converter.ledger.json"
+
+// Source state of an input
+type Source struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Hash    string    `json:"hash"`
+}
+
+// Entry conversion of an input
+type Entry struct {
+	Sources     []Source  `json:"sources"`
+	OptionsHash string    `json:"options_hash"`
+	Parts       []string  `json:"parts"`
+	ConvertedAt time.Time `json:"converted_at"`
+}
+
+type Ledger struct {
+	path    string
+	Entries map[string]Entry `json:"entries"`
+}
+
+// Load the ledger of the output directory, empty if it doesn't exist yet
+func Load(dir string) (*Ledger, error) {
+	l := &Ledger{path: filepath.Join(dir, Name), Entries: map[string]Entry{}}
+	b, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, l); err != nil {
+		return nil, err
+	}
+	if l.Entries == nil {
+		l.Entries = map[string]Entry{}
+	}
+	return l, nil
+}
+
+// Save the ledger, the file is replaced at once
+func (l *Ledger) Save() error {
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(b); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return utils.Rename(tmp, l.path)
+}
+
+// key of an input
+func key(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// UpToDate check if the sources and the options are unchanged, and the EPUB still exists.
+//
+// The content is hashed only if the size or the modification time changed.
+func (l *Ledger) UpToDate(input string, sources []string, optionsHash string) bool {
+	entry, ok := l.Entries[key(input)]
+	if !ok || entry.OptionsHash != optionsHash || len(entry.Sources) != len(sources) || len(entry.Parts) == 0 {
+		return false
+	}
+	for i, path := range sources {
+		prev := entry.Sources[i]
+		if prev.Path != key(path) {
+			return false
+		}
+		size, modTime, err := stat(path)
+		if err != nil {
+			return false
+		}
+		if size == prev.Size && modTime.Equal(prev.ModTime) {
+			continue
+		}
+		if hash, err := Hash(path); err != nil || hash != prev.Hash {
+			return false
+		}
+	}
+	for _, part := range entry.Parts {
+		if _, err := os.Stat(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Parts EPUB produced by the last conversion of the input
+func (l *Ledger) Parts(input string) []string {
+	return l.Entries[key(input)].Parts
+}
+
+// Record the conversion of the input, and remove the parts of the previous conversion that are not produced anymore.
+func (l *Ledger) Record(input string, sources []string, optionsHash string, parts []string) (removed []string, err error) {
+	entry := Entry{
+		OptionsHash: optionsHash,
+		ConvertedAt: time.Now().UTC(),
+	}
+	for _, path := range sources {
+		s := Source{Path: key(path)}
+		if s.Size, s.ModTime, err = stat(path); err != nil {
+			return
+		}
+		if s.Hash, err = Hash(path); err != nil {
+			return
+		}
+		entry.Sources = append(entry.Sources, s)
+	}
+	for _, part := range parts {
+		entry.Parts = append(entry.Parts, key(part))
+	}
+
+	for _, part := range l.Parts(input) {
+		if slices.Contains(entry.Parts, part) {
+			continue
+		}
+		if err = os.Remove(part); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		err = nil
+		removed = append(removed, part)
+	}
+
+	l.Entries[key(input)] = entry
+	return removed, l.Save()
+}
+
+// size and last modification of a file, or of all the files of a directory
+func stat(path string) (size int64, modTime time.Time, err error) {
+	err = walk(path, func(_ string, fi fs.FileInfo) error {
+		size += fi.Size()
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+		return nil
+	})
+	return size, modTime.UTC(), err
+}
+
+// Hash sha256 of the content of a file, or of all the files of a directory with their relative path
+func Hash(path string) (string, error) {
+	h := sha256.New()
+	err := walk(path, func(name string, _ fs.FileInfo) error {
+		if rel, err := filepath.Rel(path, name); err == nil && rel != "." {
+			_, _ = h.Write([]byte(filepath.ToSlash(rel) + "\x00"))
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer func(f *os.File) {
+			_ = f.Close()
+		}(f)
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// call fn for the file, or for each file of the directory in lexical order
+func walk(path string, fn func(name string, fi fs.FileInfo) error) error {
+	return filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(name, fi)
+	})
+}
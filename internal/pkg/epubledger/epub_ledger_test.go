@@ -0,0 +1,97 @@
+package epubledger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func ExampleLedger_UpToDate() {
+	dir, _ := os.MkdirTemp("", "epubledger")
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	input := filepath.Join(dir, "vol1.cbz")
+	part := filepath.Join(dir, "vol1.epub")
+	_ = os.WriteFile(input, []byte("pages"), 0644)
+	_ = os.WriteFile(part, []byte("epub"), 0644)
+
+	l, _ := Load(dir)
+	fmt.Println("not converted", l.UpToDate(input, []string{input}, "h1"))
+	if _, err := l.Record(input, []string{input}, "h1", []string{part}); err != nil {
+		fmt.Println(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		change func()
+		hash   string
+	}{
+		{"converted", func() {}, "h1"},
+		{"other options", func() {}, "h2"},
+		{"touched", func() {
+			_ = os.Chtimes(input, time.Now(), time.Now().Add(time.Hour))
+		}, "h1"},
+		{"changed", func() {
+			_ = os.WriteFile(input, []byte("other pages"), 0644)
+		}, "h1"},
+	} {
+		tc.change()
+		l, _ = Load(dir)
+		fmt.Println(tc.name, l.UpToDate(input, []string{input}, tc.hash))
+	}
+
+	_, _ = l.Record(input, []string{input}, "h1", []string{part})
+	_ = os.Remove(part)
+	l, _ = Load(dir)
+	fmt.Println("part removed", l.UpToDate(input, []string{input}, "h1"))
+	fmt.Println("other sources", l.UpToDate(input, []string{input, part}, "h1"))
+	// Output: not converted false
+	// converted true
+	// other options false
+	// touched true
+	// changed false
+	// part removed false
+	// other sources false
+}
+
+func ExampleLedger_Record() {
+	dir, _ := os.MkdirTemp("", "epubledger")
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	input := filepath.Join(dir, "vol1")
+	_ = os.Mkdir(input, 0755)
+	_ = os.WriteFile(filepath.Join(input, "p1.jpg"), []byte("p1"), 0644)
+	parts := func(names ...string) (paths []string) {
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+			_ = os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		}
+		return
+	}
+
+	for _, tc := range []struct {
+		name  string
+		parts []string
+	}{
+		{"first", parts("vol1 (1-2).epub", "vol1 (2-2).epub")},
+		{"same", parts("vol1 (1-2).epub", "vol1 (2-2).epub")},
+		{"single part", parts("vol1.epub")},
+	} {
+		l, _ := Load(dir)
+		removed, err := l.Record(input, []string{input}, "h1", tc.parts)
+		for i := range removed {
+			removed[i] = filepath.Base(removed[i])
+		}
+		l, _ = Load(dir)
+		fmt.Println(tc.name, removed, err, len(l.Parts(input)))
+	}
+	_, err := os.Stat(filepath.Join(dir, "vol1 (1-2).epub"))
+	fmt.Println(os.IsNotExist(err))
+	// Output: first [] <nil> 2
+	// same [] <nil> 2
+	// single part [vol1 (1-2).epub vol1 (2-2).epub] <nil> 1
+	// true
+}
@@ -0,0 +1,67 @@
+/*
+Package epubname render the name of the EPUB from a template.
+
+The fields are written between braces, with an optional zero padding for numbers:
+
+	{series}/{series} v{volume:02} ({part}-{parts}).epub
+
+Each directory and the file name are sanitized for FAT32 storage.
+*/
+package epubname
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubdevice"
+)
+
+// Fields available in the template
+var Fields = []string{"title", "series", "volume", "number", "author", "profile", "date", "source", "part", "parts"}
+
+var fieldRegex = regexp.MustCompile(`\{(\w+)(?::(\d+))?}`)
+
+// Check the fields of the template
+func Check(tmpl string) error {
+	if strings.TrimSpace(tmpl) == "" {
+		return fmt.Errorf("empty output template")
+	}
+	for _, m := range fieldRegex.FindAllStringSubmatch(tmpl, -1) {
+		if !slices.Contains(Fields, m[1]) {
+			return fmt.Errorf("unknown field {%s} in output template, use: %s", m[1], strings.Join(Fields, ", "))
+		}
+	}
+	return nil
+}
+
+// Render the template with the values of the fields, the result always end with .epub
+func Render(tmpl string, values map[string]string) string {
+	name := fieldRegex.ReplaceAllStringFunc(tmpl, func(s string) string {
+		m := fieldRegex.FindStringSubmatch(s)
+		v := values[m[1]]
+		if m[2] != "" {
+			width, _ := strconv.Atoi(m[2])
+			if n, err := strconv.Atoi(v); err == nil {
+				v = fmt.Sprintf("%0*d", width, n)
+			}
+		}
+		// a value can't create a directory
+		return strings.ReplaceAll(v, "/", "_")
+	})
+
+	parts := strings.Split(filepath.ToSlash(name), "/")
+	for i, p := range parts {
+		if parts[i] = epubdevice.SanitizeName(p); parts[i] == "" {
+			parts[i] = "_"
+		}
+	}
+	name = filepath.Join(parts...)
+	if !strings.EqualFold(filepath.Ext(name), ".epub") {
+		name += ".epub"
+	}
+	return name
+}
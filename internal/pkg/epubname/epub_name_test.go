@@ -0,0 +1,56 @@
+package epubname
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+func ExampleCheck() {
+	for _, tmpl := range []string{
+		"{series}/{series} v{volume:02} ({part}-{parts}).epub",
+		"{title}",
+		"",
+		"  ",
+		"{title} {chapter}",
+		"{title:3}",
+	} {
+		fmt.Printf("%q: %v\n", tmpl, Check(tmpl))
+	}
+	// Output: "{series}/{series} v{volume:02} ({part}-{parts}).epub": <nil>
+	// "{title}": <nil>
+	// "": empty output template
+	// "  ": empty output template
+	// "{title} {chapter}": unknown field {chapter} in output template, use: title, series, volume, number, author, profile, date, source, part, parts
+	// "{title:3}": <nil>
+}
+
+func ExampleRender() {
+	values := map[string]string{
+		"title":  "MyManga Vol. 5",
+		"series": "MyManga",
+		"volume": "5",
+		"part":   "1",
+		"parts":  "2",
+		"author": "AC/DC",
+	}
+	for _, tmpl := range []string{
+		"{series}/{series} v{volume:02} ({part}-{parts}).epub",
+		"{title}",
+		"{title}.EPUB",
+		"{author} - {title}",
+		"{series}: {title}?",
+		"{volume:3} {title:3}",
+		"{number}/{title}",
+		"{series}/../{title}",
+	} {
+		fmt.Printf("%s => %s\n", tmpl, filepath.ToSlash(Render(tmpl, values)))
+	}
+	// Output: {series}/{series} v{volume:02} ({part}-{parts}).epub => MyManga/MyManga v05 (1-2).epub
+	// {title} => MyManga Vol. 5.epub
+	// {title}.EPUB => MyManga Vol. 5.EPUB
+	// {author} - {title} => AC_DC - MyManga Vol. 5.epub
+	// {series}: {title}? => MyManga_ MyManga Vol. 5_.epub
+	// {volume:3} {title:3} => 005 MyManga Vol. 5.epub
+	// {number}/{title} => _/MyManga Vol. 5.epub
+	// {series}/../{title} => MyManga/_/MyManga Vol. 5.epub
+}
@@ -0,0 +1,330 @@
+/*
+Package epubopds serve a directory of EPUB as an OPDS catalog.
+
+Both versions of the catalog are available:
+  - OPDS 1.2 (atom): /opds
+  - OPDS 2.0 (json): /opds/v2
+
+The catalog is navigable by series, with an acquisition link for each part
+and the cover of the EPUB as thumbnail.
+
+The directory is scanned once, then again only when it changes.
+*/
+package epubopds
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/disintegration/gift"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
+)
+
+const (
+	atomTime          = "2006-01-02T15:04:05Z"
+	mimeNavigation    = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	mimeAcquisition   = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	mimeOPDS2         = "application/opds+json"
+	relAcquisition    = "http://opds-spec.org/acquisition"
+	relImage          = "http://opds-spec.org/image"
+	relThumbnail      = "http://opds-spec.org/image/thumbnail"
+	thumbnailMaxWidth = 200
+)
+
+type Server struct {
+	Root  string
+	Title string
+
+	index *index
+}
+
+// New create an OPDS server for the root directory
+func New(root string) Server {
+	return Server{Root: root, Title: "Go Comic Converter", index: newIndex(root)}
+}
+
+// Handler http routes of the catalog
+func (s Server) Handler() http.Handler {
+	if s.index == nil {
+		s.index = newIndex(s.Root)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/opds", http.StatusFound)
+	})
+	mux.HandleFunc("GET /opds", s.serveRoot)
+	mux.HandleFunc("GET /opds/series/{series}", s.serveSeries)
+	mux.HandleFunc("GET /opds/v2", s.serveRootV2)
+	mux.HandleFunc("GET /opds/v2/series/{series}", s.serveSeriesV2)
+	mux.HandleFunc("GET /books/{id}", s.serveBook)
+	mux.HandleFunc("GET /covers/{id}", s.serveCover(false))
+	mux.HandleFunc("GET /thumbnails/{id}", s.serveCover(true))
+	return mux
+}
+
+// ListenAndServe start the server on addr
+func (s Server) ListenAndServe(addr string) error {
+	return (&http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}).ListenAndServe()
+}
+
+func (s Server) findSeries(w http.ResponseWriter, key string) (Series, bool) {
+	series, ok, err := s.index.FindSeries(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return Series{}, false
+	}
+	if !ok {
+		http.NotFound(w, nil)
+	}
+	return series, ok
+}
+
+func (s Server) findBook(w http.ResponseWriter, id string) (Book, bool) {
+	b, ok, err := s.index.FindBook(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return Book{}, false
+	}
+	if !ok {
+		http.NotFound(w, nil)
+	}
+	return b, ok
+}
+
+// feed create an atom feed
+func (s Server) feed(id, title, self, kind string, updated time.Time) (*etree.Document, *etree.Element) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	feed := doc.CreateElement("feed")
+	feed.CreateAttr("xmlns", "http://www.w3.org/2005/Atom")
+	feed.CreateAttr("xmlns:dc", "http://purl.org/dc/terms/")
+	feed.CreateAttr("xmlns:opds", "http://opds-spec.org/2010/catalog")
+	feed.CreateElement("id").CreateText(id)
+	feed.CreateElement("title").CreateText(title)
+	feed.CreateElement("updated").CreateText(updated.UTC().Format(atomTime))
+	feed.CreateElement("author").CreateElement("name").CreateText(s.Title)
+	addLink(feed, "self", self, kind)
+	addLink(feed, "start", "/opds", mimeNavigation)
+	return doc, feed
+}
+
+func addLink(elm *etree.Element, rel, href, kind string) {
+	link := elm.CreateElement("link")
+	link.CreateAttr("rel", rel)
+	link.CreateAttr("href", href)
+	link.CreateAttr("type", kind)
+}
+
+func writeFeed(w http.ResponseWriter, doc *etree.Document, kind string) {
+	doc.Indent(2)
+	w.Header().Set("Content-Type", kind+";charset=utf-8")
+	_, _ = doc.WriteTo(w)
+}
+
+// OPDS 1.2 navigation feed: list of series
+func (s Server) serveRoot(w http.ResponseWriter, _ *http.Request) {
+	series, err := s.index.Series()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var updated time.Time
+	for _, v := range series {
+		if v.UpdatedAt().After(updated) {
+			updated = v.UpdatedAt()
+		}
+	}
+
+	doc, feed := s.feed("urn:go-comic-converter:root", s.Title, "/opds", mimeNavigation, updated)
+	for _, v := range series {
+		entry := feed.CreateElement("entry")
+		entry.CreateElement("id").CreateText("urn:go-comic-converter:series:" + v.Key())
+		entry.CreateElement("title").CreateText(v.Name)
+		entry.CreateElement("updated").CreateText(v.UpdatedAt().Format(atomTime))
+		entry.CreateElement("content").CreateText(utils.IntToString(len(v.Books)) + " book(s)")
+		addLink(entry, "subsection", "/opds/series/"+v.Key(), mimeAcquisition)
+	}
+	writeFeed(w, doc, mimeNavigation)
+}
+
+// OPDS 1.2 acquisition feed: list of books of a series
+func (s Server) serveSeries(w http.ResponseWriter, r *http.Request) {
+	series, ok := s.findSeries(w, r.PathValue("series"))
+	if !ok {
+		return
+	}
+
+	doc, feed := s.feed("urn:go-comic-converter:series:"+series.Key(), series.Name, "/opds/series/"+series.Key(), mimeAcquisition, series.UpdatedAt())
+	addLink(feed, "up", "/opds", mimeNavigation)
+	for _, b := range series.Books {
+		entry := feed.CreateElement("entry")
+		entry.CreateElement("id").CreateText(b.Identifier)
+		entry.CreateElement("title").CreateText(b.Title)
+		entry.CreateElement("updated").CreateText(b.UpdatedAt.Format(atomTime))
+		if b.Author != "" {
+			entry.CreateElement("author").CreateElement("name").CreateText(b.Author)
+		}
+		if b.Publisher != "" {
+			entry.CreateElement("dc:publisher").CreateText(b.Publisher)
+		}
+		addLink(entry, relAcquisition, "/books/"+b.Id, "application/epub+zip")
+		if b.HasCover {
+			addLink(entry, relImage, "/covers/"+b.Id, "image/jpeg")
+			addLink(entry, relThumbnail, "/thumbnails/"+b.Id, "image/jpeg")
+		}
+	}
+	writeFeed(w, doc, mimeAcquisition)
+}
+
+type linkV2 struct {
+	Rel   string `json:"rel,omitempty"`
+	Href  string `json:"href"`
+	Type  string `json:"type"`
+	Title string `json:"title,omitempty"`
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", mimeOPDS2)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// OPDS 2.0 navigation: list of series
+func (s Server) serveRootV2(w http.ResponseWriter, _ *http.Request) {
+	series, err := s.index.Series()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	navigation := make([]linkV2, 0, len(series))
+	for _, v := range series {
+		navigation = append(navigation, linkV2{
+			Href:  "/opds/v2/series/" + v.Key(),
+			Type:  mimeOPDS2,
+			Title: v.Name,
+		})
+	}
+	writeJson(w, map[string]any{
+		"metadata":   map[string]any{"title": s.Title},
+		"links":      []linkV2{{Rel: "self", Href: "/opds/v2", Type: mimeOPDS2}},
+		"navigation": navigation,
+	})
+}
+
+// OPDS 2.0 publications of a series
+func (s Server) serveSeriesV2(w http.ResponseWriter, r *http.Request) {
+	series, ok := s.findSeries(w, r.PathValue("series"))
+	if !ok {
+		return
+	}
+
+	publications := make([]map[string]any, 0, len(series.Books))
+	for _, b := range series.Books {
+		metadata := map[string]any{
+			"@type":      "http://schema.org/Book",
+			"identifier": b.Identifier,
+			"title":      b.Title,
+			"modified":   b.UpdatedAt.Format(atomTime),
+			"belongsTo": map[string]any{
+				"series": map[string]any{"name": b.Series, "position": b.SeriesIndex},
+			},
+		}
+		if b.Author != "" {
+			metadata["author"] = b.Author
+		}
+		if b.Publisher != "" {
+			metadata["publisher"] = b.Publisher
+		}
+		publication := map[string]any{
+			"metadata": metadata,
+			"links":    []linkV2{{Rel: relAcquisition, Href: "/books/" + b.Id, Type: "application/epub+zip"}},
+		}
+		if b.HasCover {
+			publication["images"] = []linkV2{
+				{Href: "/covers/" + b.Id, Type: "image/jpeg"},
+				{Href: "/thumbnails/" + b.Id, Type: "image/jpeg"},
+			}
+		}
+		publications = append(publications, publication)
+	}
+	writeJson(w, map[string]any{
+		"metadata": map[string]any{"title": series.Name},
+		"links": []linkV2{
+			{Rel: "self", Href: "/opds/v2/series/" + series.Key(), Type: mimeOPDS2},
+			{Rel: "up", Href: "/opds/v2", Type: mimeOPDS2},
+		},
+		"publications": publications,
+	})
+}
+
+func (s Server) serveBook(w http.ResponseWriter, r *http.Request) {
+	b, ok := s.findBook(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/epub+zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(b.Path)}))
+	http.ServeFile(w, r, b.Path)
+}
+
+// serve the cover of the EPUB, reduce it if thumbnail is requested
+func (s Server) serveCover(thumbnail bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, ok := s.findBook(w, r.PathValue("id"))
+		if !ok {
+			return
+		}
+		if !b.HasCover {
+			http.NotFound(w, r)
+			return
+		}
+
+		z, err := zip.OpenReader(b.Path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer func(z *zip.ReadCloser) {
+			_ = z.Close()
+		}(z)
+
+		f, err := z.Open("OEBPS/Images/cover.jpeg")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer func(f io.ReadCloser) {
+			_ = f.Close()
+		}(f)
+
+		w.Header().Set("Content-Type", "image/jpeg")
+		if !thumbnail {
+			_, _ = io.Copy(w, f)
+			return
+		}
+
+		src, err := jpeg.Decode(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		g := gift.New(gift.ResizeToFit(thumbnailMaxWidth, thumbnailMaxWidth*2, gift.LanczosResampling))
+		dst := image.NewRGBA(g.Bounds(src.Bounds()))
+		g.Draw(dst, src)
+		_ = jpeg.Encode(w, dst, &jpeg.Options{Quality: 85})
+	}
+}
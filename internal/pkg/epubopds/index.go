@@ -0,0 +1,108 @@
+package epubopds
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// the directories are checked for changes at most once per indexCheckInterval
+	indexCheckInterval = 2 * time.Second
+	// the library is fully scanned again after indexTTL, to catch files replaced in place
+	indexTTL = 5 * time.Minute
+)
+
+// index of the library, scanned once and shared by the requests.
+//
+// It is refreshed when the modification time of a directory changes, or after indexTTL.
+type index struct {
+	root string
+
+	mu        sync.Mutex
+	series    []Series
+	books     map[string]Book
+	dirs      map[string]time.Time
+	scannedAt time.Time
+	checkedAt time.Time
+}
+
+func newIndex(root string) *index {
+	return &index{root: root}
+}
+
+// Series of the library, scanned again if it changed
+func (x *index) Series() ([]Series, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if err := x.refresh(); err != nil {
+		return nil, err
+	}
+	return x.series, nil
+}
+
+// FindSeries by key
+func (x *index) FindSeries(key string) (Series, bool, error) {
+	series, err := x.Series()
+	if err != nil {
+		return Series{}, false, err
+	}
+	for _, v := range series {
+		if v.Key() == key {
+			return v, true, nil
+		}
+	}
+	return Series{}, false, nil
+}
+
+// FindBook by id
+func (x *index) FindBook(id string) (Book, bool, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if err := x.refresh(); err != nil {
+		return Book{}, false, err
+	}
+	b, ok := x.books[id]
+	return b, ok, nil
+}
+
+// refresh scan the library if needed, the caller must hold the lock
+func (x *index) refresh() error {
+	now := time.Now()
+	if !x.scannedAt.IsZero() && now.Sub(x.scannedAt) < indexTTL {
+		if now.Sub(x.checkedAt) < indexCheckInterval || !x.changed() {
+			return nil
+		}
+	}
+
+	previous := map[string]Book{}
+	for _, b := range x.books {
+		previous[b.Path] = b
+	}
+	series, dirs, err := scan(x.root, previous)
+	if err != nil {
+		return err
+	}
+
+	x.series, x.dirs = series, dirs
+	x.books = map[string]Book{}
+	for _, v := range series {
+		for _, b := range v.Books {
+			x.books[b.Id] = b
+		}
+	}
+	x.scannedAt, x.checkedAt = now, now
+	return nil
+}
+
+// changed check if a directory has been modified since the last scan
+func (x *index) changed() bool {
+	x.checkedAt = time.Now()
+	for dir, modTime := range x.dirs {
+		fi, err := os.Stat(dir)
+		if err != nil || !fi.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package epubopds
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+type Book struct {
+	Id          string
+	Path        string
+	Title       string
+	Author      string
+	Publisher   string
+	Identifier  string
+	Series      string
+	SeriesIndex float64
+	UpdatedAt   time.Time
+	Size        int64
+	HasCover    bool
+
+	// modification time of the file, UpdatedAt can come from the metadata
+	modTime time.Time
+}
+
+type Series struct {
+	Name  string
+	Books []Book
+}
+
+// Key url safe key of the series
+func (s Series) Key() string {
+	return bookId(s.Name)
+}
+
+func bookId(s string) string {
+	h := sha1.Sum([]byte(s))
+	return hex.EncodeToString(h[:8])
+}
+
+// read metadata from the content.opf of the EPUB
+func readBook(root, path string) (Book, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return Book{}, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Book{}, err
+	}
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return Book{}, err
+	}
+	defer func(r *zip.ReadCloser) {
+		_ = r.Close()
+	}(r)
+
+	b := Book{
+		Id:        bookId(filepath.ToSlash(rel)),
+		Path:      path,
+		Title:     strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		UpdatedAt: fi.ModTime().UTC(),
+		Size:      fi.Size(),
+		modTime:   fi.ModTime(),
+	}
+
+	for _, f := range r.File {
+		switch f.Name {
+		case "OEBPS/Images/cover.jpeg":
+			b.HasCover = true
+		case "OEBPS/content.opf":
+			if err := b.readContent(f); err != nil {
+				return Book{}, err
+			}
+		}
+	}
+
+	if b.Series == "" {
+		b.Series = b.Title
+	}
+	if b.Identifier == "" {
+		b.Identifier = "urn:go-comic-converter:book:" + b.Id
+	}
+
+	return b, nil
+}
+
+func (b *Book) readContent(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func(rc io.ReadCloser) {
+		_ = rc.Close()
+	}(rc)
+
+	doc := etree.NewDocument()
+	if _, err = doc.ReadFrom(rc); err != nil {
+		return err
+	}
+
+	metadata := doc.FindElement("//metadata")
+	if metadata == nil {
+		return nil
+	}
+	for _, m := range metadata.ChildElements() {
+		switch m.FullTag() {
+		case "dc:title":
+			b.Title = m.Text()
+		case "dc:creator":
+			b.Author = m.Text()
+		case "dc:publisher":
+			b.Publisher = m.Text()
+		case "dc:identifier":
+			b.Identifier = m.Text()
+		case "meta":
+			switch m.SelectAttrValue("name", "") {
+			case "calibre:series":
+				b.Series = m.SelectAttrValue("content", "")
+			case "calibre:series_index":
+				b.SeriesIndex, _ = strconv.ParseFloat(m.SelectAttrValue("content", ""), 64)
+			}
+			if m.SelectAttrValue("property", "") == "dcterms:modified" {
+				if t, err := time.Parse("2006-01-02T15:04:05Z", m.Text()); err == nil {
+					b.UpdatedAt = t
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Scan the directory and group the EPUB by series.
+func Scan(root string) ([]Series, error) {
+	series, _, err := scan(root, nil)
+	return series, err
+}
+
+// scan the directory, the books of the previous scan are reused if their file didn't change.
+//
+// The modification time of the directories is returned to detect the changes.
+func scan(root string, previous map[string]Book) ([]Series, map[string]time.Time, error) {
+	bySeries := map[string]*Series{}
+	dirs := map[string]time.Time{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if fi, err := d.Info(); err == nil {
+				dirs[path] = fi.ModTime()
+			}
+			return nil
+		}
+		if strings.ToLower(filepath.Ext(path)) != ".epub" || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		b, ok := previousBook(previous, path, d)
+		if !ok {
+			if b, err = readBook(root, path); err != nil {
+				// skip invalid EPUB, they may be in progress
+				return nil
+			}
+		}
+		s, ok := bySeries[b.Series]
+		if !ok {
+			s = &Series{Name: b.Series}
+			bySeries[b.Series] = s
+		}
+		s.Books = append(s.Books, b)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	series := make([]Series, 0, len(bySeries))
+	for _, s := range bySeries {
+		sort.Slice(s.Books, func(i, j int) bool {
+			if s.Books[i].SeriesIndex == s.Books[j].SeriesIndex {
+				return s.Books[i].Title < s.Books[j].Title
+			}
+			return s.Books[i].SeriesIndex < s.Books[j].SeriesIndex
+		})
+		series = append(series, *s)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Name < series[j].Name
+	})
+	return series, dirs, nil
+}
+
+// previousBook book of the previous scan, if the file has the same size and modification time
+func previousBook(previous map[string]Book, path string, d fs.DirEntry) (Book, bool) {
+	b, ok := previous[path]
+	if !ok {
+		return Book{}, false
+	}
+	fi, err := d.Info()
+	if err != nil || fi.Size() != b.Size || !fi.ModTime().Equal(b.modTime) {
+		return Book{}, false
+	}
+	return b, true
+}
+
+// UpdatedAt most recent update of the series
+func (s Series) UpdatedAt() time.Time {
+	var t time.Time
+	for _, b := range s.Books {
+		if b.UpdatedAt.After(t) {
+			t = b.UpdatedAt
+		}
+	}
+	return t
+}
@@ -0,0 +1,184 @@
+// Package epubpreview create an HTML report of the processed pages.
+//
+// Each page shows the source and the processed thumbnails, the crop area and its flags.
+// The pages are grouped by spread, as the reader will display them.
+package epubpreview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html/template"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimage"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubtemplates"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
+)
+
+// Part of the EPUB to preview
+type Part struct {
+	Cover   epubimage.EPUBImage
+	Content epubtemplates.Content
+}
+
+type page struct {
+	Name      string
+	Position  string
+	Flags     []string
+	Source    template.URL
+	Processed template.URL
+	Crop      template.CSS
+}
+
+type spread struct {
+	Pages []page
+}
+
+type part struct {
+	Number  int
+	Spreads []spread
+}
+
+// data uri of the image
+func dataURI(img image.Image) template.URL {
+	if img == nil {
+		return ""
+	}
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, img, &jpeg.Options{Quality: 75}); err != nil {
+		return ""
+	}
+	return template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(b.Bytes()))
+}
+
+// position of the crop area relative to the source
+func cropStyle(p *epubimage.Preview) template.CSS {
+	w, h := float64(p.Bounds.Dx()), float64(p.Bounds.Dy())
+	if w == 0 || h == 0 {
+		return ""
+	}
+	pct := func(v int, total float64) string {
+		return utils.FloatToString(float64(v)*100/total, 2) + "%"
+	}
+	return template.CSS(
+		"left:" + pct(p.Crop.Min.X-p.Bounds.Min.X, w) +
+			";top:" + pct(p.Crop.Min.Y-p.Bounds.Min.Y, h) +
+			";width:" + pct(p.Crop.Dx(), w) +
+			";height:" + pct(p.Crop.Dy(), h),
+	)
+}
+
+func newPage(img epubimage.EPUBImage, position string) page {
+	p := page{
+		Name:     filepath.Join(img.Path, img.Name),
+		Position: position,
+	}
+	if img.Part > 0 {
+		p.Flags = append(p.Flags, "split "+utils.IntToString(img.Part)+"/2")
+	}
+	if img.DoublePage {
+		p.Flags = append(p.Flags, "double page")
+	}
+	if img.IsBlank {
+		p.Flags = append(p.Flags, "blank")
+	}
+	if img.Error != nil {
+		p.Flags = append(p.Flags, "corrupted")
+	}
+	if img.Preview == nil {
+		p.Flags = append(p.Flags, "not sampled")
+		return p
+	}
+	p.Source = dataURI(img.Preview.Source)
+	p.Processed = dataURI(img.Preview.Processed)
+	p.Crop = cropStyle(img.Preview)
+	return p
+}
+
+// group the spine items into spreads, a centered page is alone.
+func spreads(cover epubimage.EPUBImage, content epubtemplates.Content) []spread {
+	pages := map[string]epubimage.EPUBImage{}
+	for _, img := range content.Images {
+		pages[img.PageKey()] = img
+	}
+
+	order := map[string]int{"left": 0, "center": 1, "": 1, "right": 2}
+	result := make([]spread, 0)
+	current := spread{}
+	flush := func() {
+		if len(current.Pages) > 0 {
+			sort.SliceStable(current.Pages, func(i, j int) bool {
+				return order[current.Pages[i].Position] < order[current.Pages[j].Position]
+			})
+			result = append(result, current)
+		}
+		current = spread{}
+	}
+
+	for _, item := range content.Spine() {
+		var p page
+		switch {
+		case item.Idref == "page_title":
+			p = newPage(cover, item.Position)
+			p.Name, p.Flags = "title", nil
+		case pages[item.Idref].Name != "":
+			p = newPage(pages[item.Idref], item.Position)
+		default:
+			p = page{Name: "blank", Position: item.Position}
+		}
+
+		if item.Position == "center" || item.Position == "" {
+			flush()
+			current.Pages = append(current.Pages, p)
+			flush()
+			continue
+		}
+		for _, c := range current.Pages {
+			if c.Position == item.Position {
+				flush()
+				break
+			}
+		}
+		current.Pages = append(current.Pages, p)
+		if len(current.Pages) == 2 {
+			flush()
+		}
+	}
+	flush()
+	return result
+}
+
+// Write the HTML report
+func Write(path string, title string, parts []Part, removed []epubimage.EPUBImage) error {
+	data := struct {
+		Title   string
+		Parts   []part
+		Removed []page
+	}{Title: title}
+
+	for i, p := range parts {
+		data.Parts = append(data.Parts, part{i + 1, spreads(p.Cover, p.Content)})
+	}
+	for _, img := range removed {
+		data.Removed = append(data.Removed, newPage(img, ""))
+	}
+
+	tmpl, err := template.New("preview").Parse(epubtemplates.Preview)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = tmpl.Execute(f, data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
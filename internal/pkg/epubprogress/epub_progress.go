@@ -2,13 +2,13 @@
 package epubprogress
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/schollz/progressbar/v3"
 
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubevent"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
 )
 
@@ -27,15 +27,20 @@ type EPUBProgress interface {
 }
 
 func New(o Options) EPUBProgress {
-	if o.Quiet {
-		return progressbar.DefaultSilent(int64(o.Max))
+	if o.Json && !o.Quiet {
+		return &jsonprogress{o: o}
 	}
 
-	if o.Json {
-		return &jsonprogress{
-			o: o,
-			e: json.NewEncoder(os.Stdout),
-		}
+	bar := newBar(o)
+	if epubevent.Enabled() {
+		return eventprogress{bar, &jsonprogress{o: o}}
+	}
+	return bar
+}
+
+func newBar(o Options) EPUBProgress {
+	if o.Quiet {
+		return progressbar.DefaultSilent(int64(o.Max))
 	}
 
 	fmtJob := utils.FormatNumberOfDigits(o.TotalJob)
@@ -1,33 +1,45 @@
 package epubprogress
 
 import (
-	"encoding/json"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubevent"
 )
 
 type jsonprogress struct {
 	o       Options
-	e       *json.Encoder
 	current int
 }
 
 func (p *jsonprogress) Add(num int) error {
 	p.current += num
-	return p.e.Encode(map[string]any{
-		"type": "epubprogress",
-		"data": map[string]any{
-			"epubprogress": map[string]any{
-				"current": p.current,
-				"total":   p.o.Max,
-			},
-			"steps": map[string]any{
-				"current": p.o.CurrentJob,
-				"total":   p.o.TotalJob,
-			},
-			"description": p.o.Description,
+	epubevent.Emit(epubevent.TypeProgress, map[string]any{
+		"epubprogress": map[string]any{
+			"current": p.current,
+			"total":   p.o.Max,
 		},
+		"steps": map[string]any{
+			"current": p.o.CurrentJob,
+			"total":   p.o.TotalJob,
+		},
+		"description": p.o.Description,
 	})
+	return nil
 }
 
 func (p *jsonprogress) Close() error {
 	return nil
 }
+
+// eventprogress show the progress bar and write the events in the NDJSON file
+type eventprogress struct {
+	bar    EPUBProgress
+	events *jsonprogress
+}
+
+func (p eventprogress) Add(num int) error {
+	_ = p.events.Add(num)
+	return p.bar.Add(num)
+}
+
+func (p eventprogress) Close() error {
+	return p.bar.Close()
+}
@@ -1,6 +1,10 @@
 package epubtemplates
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
 	"github.com/beevik/etree"
 
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimage"
@@ -10,6 +14,7 @@ import (
 
 type Content struct {
 	Title        string
+	Series       string
 	HasTitlePage bool
 	UID          string
 	Author       string
@@ -71,11 +76,7 @@ func (o Content) String() string {
 		spine.CreateAttr("page-progression-direction", "ltr")
 	}
 
-	if o.ImageOptions.View.PortraitOnly {
-		addToElement(spine, o.getSpinePortrait)
-	} else {
-		addToElement(spine, o.getSpineAuto)
-	}
+	addToElement(spine, o.getSpine)
 
 	guide := pkg.CreateElement("guide")
 	addToElement(guide, o.getGuide)
@@ -113,6 +114,12 @@ func (o Content) getMeta() []tag {
 			{"meta", tagAttrs{"property": "rendition:spread"}, "none"},
 			{"meta", tagAttrs{"property": "rendition:orientation"}, "portrait"},
 		}...)
+	} else if o.ImageOptions.MergeSpread {
+		metas = append(metas, []tag{
+			{"meta", tagAttrs{"property": "rendition:layout"}, "pre-paginated"},
+			{"meta", tagAttrs{"property": "rendition:spread"}, "none"},
+			{"meta", tagAttrs{"property": "rendition:orientation"}, "auto"},
+		}...)
 	} else {
 		metas = append(metas, []tag{
 			{"meta", tagAttrs{"property": "rendition:layout"}, "pre-paginated"},
@@ -129,12 +136,10 @@ func (o Content) getMeta() []tag {
 
 	metas = append(metas, tag{"meta", tagAttrs{"name": "cover", "content": "img_cover"}, ""})
 
-	if o.Total > 1 {
-		metas = append(
-			metas,
-			tag{"meta", tagAttrs{"name": "calibre:series", "content": o.Title}, ""},
-			tag{"meta", tagAttrs{"name": "calibre:series_index", "content": utils.IntToString(o.Current)}, ""},
-		)
+	// the volumes of a series are grouped by the readers and the catalogs
+	metas = append(metas, tag{"meta", tagAttrs{"name": "calibre:series", "content": o.Series}, ""})
+	if o.Current > 0 {
+		metas = append(metas, tag{"meta", tagAttrs{"name": "calibre:series_index", "content": utils.IntToString(o.Current)}, ""})
 	}
 
 	return metas
@@ -169,7 +174,7 @@ func (o Content) getManifest() []tag {
 			tag{"item", tagAttrs{"id": "img_title", "href": "Images/title.jpeg", "media-type": "image/jpeg"}, ""},
 		)
 
-		if !o.ImageOptions.View.PortraitOnly {
+		if !o.ImageOptions.SinglePageView() {
 			items = append(items, tag{"item", tagAttrs{"id": "space_title", "href": "Text/space_title.xhtml", "media-type": "application/xhtml+xml"}, ""})
 		}
 	}
@@ -178,8 +183,8 @@ func (o Content) getManifest() []tag {
 	for _, img := range o.Images {
 		addTag(
 			img,
-			!o.ImageOptions.View.PortraitOnly &&
-				(img.DoublePage ||
+			!o.ImageOptions.SinglePageView() &&
+				(img.DoublePage || img.Spread ||
 					(!o.ImageOptions.KeepDoublePageIfSplit && img.Part == 1) ||
 					(img.Part == 0 && img == lastImage)))
 	}
@@ -228,7 +233,8 @@ func (o Content) getSpineAuto() []tag {
 		}
 	}
 	for i, img := range o.Images {
-		if (img.DoublePage || img.Part == 1) && o.ImageOptions.Manga == isOnTheRight {
+		// split double page and spread start on the first side
+		if (img.DoublePage || img.Part == 1 || img.Spread) && o.ImageOptions.Manga == isOnTheRight {
 			spine = append(spine, tag{
 				"itemref",
 				tagAttrs{"idref": img.SpaceKey(), "properties": getSpreadBlank()},
@@ -273,6 +279,67 @@ func (o Content) getSpinePortrait() []tag {
 	return spine
 }
 
+// Layout human-readable version of the spine.
+//
+// It shows the position of each page, the blank pages inserted and the double pages.
+func (o Content) Layout() []string {
+	names := map[string]string{
+		"page_title":  "title",
+		"space_title": "blank",
+	}
+	for _, img := range o.Images {
+		name := filepath.Join(img.Path, img.Name)
+		if img.Part > 0 {
+			name += " (part " + utils.IntToString(img.Part) + ")"
+		}
+		if img.DoublePage {
+			name += " (double page)"
+		}
+		names[img.PageKey()] = name
+		names[img.SpaceKey()] = "blank"
+	}
+
+	spine := o.Spine()
+	layout := make([]string, 0, len(spine))
+	for _, item := range spine {
+		position := item.Position
+		if position == "" {
+			position = "-"
+		}
+		layout = append(layout, fmt.Sprintf("%-6s %s", position, names[item.Idref]))
+	}
+	return layout
+}
+
+// getSpine spine of the content, the pages are shown one at a time in single page view
+func (o Content) getSpine() []tag {
+	if o.ImageOptions.SinglePageView() {
+		return o.getSpinePortrait()
+	}
+	return o.getSpineAuto()
+}
+
+type SpineItem struct {
+	Idref    string
+	Position string
+}
+
+// Spine items in reading order, with their position: left, right, center, or empty in single page view.
+func (o Content) Spine() []SpineItem {
+	spine := o.getSpine()
+	items := make([]SpineItem, 0, len(spine))
+	for _, t := range spine {
+		item := SpineItem{Idref: t.attrs["idref"]}
+		for _, p := range []string{"left", "right", "center"} {
+			if strings.Contains(t.attrs["properties"], "page-spread-"+p) {
+				item.Position = p
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
 // getGuide Section guide of the content
 func (o Content) getGuide() []tag {
 	return []tag{
@@ -0,0 +1,6 @@
+package epubtemplates
+
+import _ "embed"
+
+//go:embed "preview.html.tmpl"
+var Preview string
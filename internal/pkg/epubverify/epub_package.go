@@ -0,0 +1,207 @@
+package epubverify
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+	_ "golang.org/x/image/webp"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimage"
+)
+
+// media type expected for each extension, as declared by the content
+var mediaTypes = map[string]string{
+	".xhtml": "application/xhtml+xml",
+	".css":   "text/css",
+	".jpeg":  "image/jpeg",
+	".jpg":   "image/jpeg",
+	".png":   "image/png",
+	".webp":  "image/webp",
+	".gif":   "image/gif",
+}
+
+type item struct {
+	Path       string
+	MediaType  string
+	Properties string
+}
+
+// check the manifest, the spine, the nav and the pages of the package document
+func (r *Report) checkPackage(z *zip.Reader, opf string) {
+	doc, err := readXML(z, opf)
+	if err != nil {
+		r.errorf("%s: %v", opf, err)
+		return
+	}
+	dir := path.Dir(opf)
+
+	manifest := map[string]item{}
+	paths := map[string]bool{}
+	for _, el := range doc.FindElements("//manifest/item") {
+		id, href := el.SelectAttrValue("id", ""), el.SelectAttrValue("href", "")
+		if _, ok := manifest[id]; ok {
+			r.errorf("manifest: duplicate id %q", id)
+			continue
+		}
+		it := item{path.Join(dir, href), el.SelectAttrValue("media-type", ""), el.SelectAttrValue("properties", "")}
+		manifest[id] = it
+		paths[it.Path] = true
+
+		if _, err = fs.Stat(z, it.Path); err != nil {
+			r.errorf("manifest: %s is missing", it.Path)
+		}
+		if expected, ok := mediaTypes[strings.ToLower(path.Ext(href))]; !ok || expected != it.MediaType {
+			r.errorf("manifest: %s has media type %q, expected %q", it.Path, it.MediaType, expected)
+		}
+	}
+
+	for _, f := range z.File {
+		if f.Name != "mimetype" && !strings.HasPrefix(f.Name, "META-INF/") && f.Name != opf && !paths[f.Name] {
+			r.errorf("%s is not in the manifest", f.Name)
+		}
+	}
+
+	if cover := doc.FindElement("//metadata/meta[@name='cover']"); cover != nil {
+		if _, ok := manifest[cover.SelectAttrValue("content", "")]; !ok {
+			r.errorf("metadata: cover %q is not in the manifest", cover.SelectAttrValue("content", ""))
+		}
+	}
+
+	spine := doc.FindElements("//spine/itemref")
+	if len(spine) == 0 {
+		r.errorf("spine is empty")
+	}
+	pages := make([]string, 0, len(spine))
+	for _, el := range spine {
+		idref := el.SelectAttrValue("idref", "")
+		it, ok := manifest[idref]
+		if !ok {
+			r.errorf("spine: idref %q is not in the manifest", idref)
+			continue
+		}
+		if it.MediaType != "application/xhtml+xml" {
+			r.errorf("spine: idref %q is not a page", idref)
+			continue
+		}
+		pages = append(pages, it.Path)
+	}
+
+	nav := ""
+	for _, it := range manifest {
+		if strings.Contains(it.Properties, "nav") {
+			nav = it.Path
+		}
+	}
+	if nav == "" {
+		r.errorf("manifest: nav is missing")
+	} else {
+		r.checkNav(z, nav, paths)
+	}
+
+	var width, height int
+	if el := doc.FindElement("//metadata/meta[@name='original-resolution']"); el == nil {
+		r.errorf("metadata: original-resolution is missing")
+		return
+	} else if _, err = fmt.Sscanf(el.SelectAttrValue("content", ""), "%dx%d", &width, &height); err != nil {
+		r.errorf("metadata: invalid original-resolution %q", el.SelectAttrValue("content", ""))
+		return
+	}
+	for _, page := range pages {
+		r.checkPage(z, page, width, height, paths)
+	}
+}
+
+// each link of the nav should point to a file of the manifest
+func (r *Report) checkNav(z *zip.Reader, nav string, paths map[string]bool) {
+	doc, err := readXML(z, nav)
+	if err != nil {
+		r.errorf("%s: %v", nav, err)
+		return
+	}
+	links := doc.FindElements("//nav//a")
+	if len(links) == 0 {
+		r.errorf("%s: no link in nav", nav)
+	}
+	for _, a := range links {
+		href, _, _ := strings.Cut(a.SelectAttrValue("href", ""), "#")
+		if target := path.Join(path.Dir(nav), href); !paths[target] {
+			r.errorf("%s: link %q is not in the manifest", nav, href)
+		}
+	}
+}
+
+// the viewport of the page should match the resolution of the book, or be two pages wide for a merged spread,
+// and the image should be displayed at the size computed from its dimensions
+func (r *Report) checkPage(z *zip.Reader, page string, width, height int, paths map[string]bool) {
+	doc, err := readXML(z, page)
+	if err != nil {
+		r.errorf("%s: %v", page, err)
+		return
+	}
+	// a merged spread declares its width on the body
+	if body := doc.FindElement("//body"); body != nil && body.SelectAttrValue("style", "") == "width:"+strconv.Itoa(width*2)+"px" {
+		width *= 2
+	}
+	viewport := doc.FindElement("//head/meta[@name='viewport']")
+	if viewport == nil {
+		r.errorf("%s: viewport is missing", page)
+	} else if content, expected := viewport.SelectAttrValue("content", ""), "width="+strconv.Itoa(width)+",height="+strconv.Itoa(height); content != expected {
+		r.errorf("%s: viewport %q, expected %q", page, content, expected)
+	}
+
+	img := doc.FindElement("//body//img")
+	if img == nil {
+		// blank page
+		return
+	}
+	src := path.Join(path.Dir(page), img.SelectAttrValue("src", ""))
+	if !paths[src] {
+		r.errorf("%s: image %s is not in the manifest", page, src)
+		return
+	}
+	f, err := z.Open(src)
+	if err != nil {
+		return
+	}
+	defer func(f fs.File) {
+		_ = f.Close()
+	}(f)
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		r.errorf("%s: %v", src, err)
+		return
+	}
+
+	style := map[string]string{}
+	for _, s := range strings.Split(img.SelectAttrValue("style", ""), ";") {
+		if k, v, ok := strings.Cut(s, ":"); ok {
+			style[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	relWidth, relHeight := epubimage.EPUBImage{Width: config.Width, Height: config.Height}.RelSize(width, height)
+	expected := strconv.Itoa(relWidth) + "x" + strconv.Itoa(relHeight)
+	if size := strings.TrimSuffix(style["width"], "px") + "x" + strings.TrimSuffix(style["height"], "px"); size != expected {
+		r.errorf("%s: image %s (%dx%d) displayed at %s, expected %s", page, src, config.Width, config.Height, size, expected)
+	}
+}
+
+func readXML(z *zip.Reader, name string) (*etree.Document, error) {
+	b, err := fs.ReadFile(z, name)
+	if err != nil {
+		return nil, err
+	}
+	doc := etree.NewDocument()
+	if err = doc.ReadFromBytes(b); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
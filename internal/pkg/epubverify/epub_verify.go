@@ -0,0 +1,121 @@
+// Package epubverify check the structure of an EPUB.
+package epubverify
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/beevik/etree"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
+)
+
+type Report struct {
+	Path   string   `json:"path"`
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors"`
+}
+
+func (r *Report) errorf(format string, a ...any) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, a...))
+}
+
+// Verify the EPUB at path
+func Verify(path string) Report {
+	r := Report{Path: path, Errors: []string{}}
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		r.errorf("cannot open: %v", err)
+		return r
+	}
+	defer func(z *zip.ReadCloser) {
+		_ = z.Close()
+	}(z)
+
+	r.checkMimetype(z.File)
+	if opf := r.checkContainer(&z.Reader); opf != "" {
+		r.checkPackage(&z.Reader, opf)
+	}
+	r.checkCRC(z.File)
+
+	r.Valid = len(r.Errors) == 0
+	return r
+}
+
+// mimetype should be the first file, stored without compression
+func (r *Report) checkMimetype(files []*zip.File) {
+	if len(files) == 0 || files[0].Name != "mimetype" {
+		r.errorf("mimetype is not the first file")
+		return
+	}
+	if files[0].Method != zip.Store {
+		r.errorf("mimetype is compressed")
+	}
+	if b, err := readFile(files[0]); err != nil || string(b) != "application/epub+zip" {
+		r.errorf("mimetype should be application/epub+zip")
+	}
+}
+
+// container.xml should point to an existing package document, return its path
+func (r *Report) checkContainer(z *zip.Reader) string {
+	f, err := z.Open("META-INF/container.xml")
+	if err != nil {
+		r.errorf("META-INF/container.xml is missing")
+		return ""
+	}
+	defer func(f io.ReadCloser) {
+		_ = f.Close()
+	}(f)
+
+	doc := etree.NewDocument()
+	if _, err = doc.ReadFrom(f); err != nil {
+		r.errorf("META-INF/container.xml: %v", err)
+		return ""
+	}
+	rootfile := doc.FindElement("//rootfile")
+	if rootfile == nil {
+		r.errorf("META-INF/container.xml: rootfile is missing")
+		return ""
+	}
+	opf := rootfile.SelectAttrValue("full-path", "")
+	if _, err = fs.Stat(z, opf); err != nil {
+		r.errorf("package document %q is missing", opf)
+		return ""
+	}
+	return opf
+}
+
+// the checksum is verified by the zip reader once the file is fully read
+func (r *Report) checkCRC(files []*zip.File) {
+	for _, f := range files {
+		if _, err := readFile(f); err != nil {
+			r.errorf("%s: %v", f.Name, err)
+		}
+	}
+}
+
+func readFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func(rc io.ReadCloser) {
+		_ = rc.Close()
+	}(rc)
+	return io.ReadAll(rc)
+}
+
+func (r Report) String() string {
+	if r.Valid {
+		return r.Path + ": OK"
+	}
+	var b strings.Builder
+	b.WriteString(r.Path + ": " + utils.IntToString(len(r.Errors)) + " errors")
+	for _, e := range r.Errors {
+		b.WriteString("\n  - " + e)
+	}
+	return b.String()
+}
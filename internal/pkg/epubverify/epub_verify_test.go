@@ -0,0 +1,117 @@
+package epubverify
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+type file struct {
+	Name   string
+	Body   string
+	Method uint16
+}
+
+const (
+	container = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`
+	opf = `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+<metadata><meta name="cover" content="img"/><meta name="original-resolution" content="10x20"/></metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="page" href="Text/page.xhtml" media-type="application/xhtml+xml"/>
+<item id="img" href="Images/page.png" media-type="image/png"/>
+</manifest>
+<spine><itemref idref="page"/></spine>
+</package>`
+	nav = `<?xml version="1.0"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><body><nav><ol><li><a href="Text/page.xhtml">Page</a></li></ol></nav></body></html>`
+	page = `<?xml version="1.0"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><meta name="viewport" content="width=10,height=20"/></head>
+<body><div><img src="../Images/page.png" style="width:10px; height:20px"/></div></body></html>`
+)
+
+// files of a minimal valid EPUB, with the given overrides, an empty body removes the file
+func epub(overrides ...file) []file {
+	b := bytes.Buffer{}
+	_ = png.Encode(&b, image.NewGray(image.Rect(0, 0, 10, 20)))
+	files := []file{
+		{"mimetype", "application/epub+zip", zip.Store},
+		{"META-INF/container.xml", container, zip.Deflate},
+		{"OEBPS/content.opf", opf, zip.Deflate},
+		{"OEBPS/nav.xhtml", nav, zip.Deflate},
+		{"OEBPS/Text/page.xhtml", page, zip.Deflate},
+		{"OEBPS/Images/page.png", b.String(), zip.Deflate},
+	}
+	for _, o := range overrides {
+		for i, f := range files {
+			if f.Name == o.Name {
+				files[i] = o
+			}
+		}
+	}
+	return files
+}
+
+func write(path string, files []file) {
+	f, _ := os.Create(path)
+	defer func() {
+		_ = f.Close()
+	}()
+	w := zip.NewWriter(f)
+	for _, file := range files {
+		if file.Body == "" {
+			continue
+		}
+		fw, _ := w.CreateHeader(&zip.FileHeader{Name: file.Name, Method: file.Method})
+		_, _ = fw.Write([]byte(file.Body))
+	}
+	_ = w.Close()
+}
+
+func ExampleVerify() {
+	dir, _ := os.MkdirTemp("", "epubverify")
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	for _, tc := range []struct {
+		name  string
+		files []file
+	}{
+		{"valid", epub()},
+		{"compressed mimetype", epub(file{"mimetype", "application/epub+zip", zip.Deflate})},
+		{"wrong mimetype", epub(file{"mimetype", "application/zip", zip.Store})},
+		{"no mimetype", epub(file{Name: "mimetype"})},
+		{"no container", epub(file{Name: "META-INF/container.xml"})},
+		{"no rootfile", epub(file{"META-INF/container.xml", "<container/>", zip.Deflate})},
+		{"no package", epub(file{Name: "OEBPS/content.opf"})},
+		{"no image", epub(file{Name: "OEBPS/Images/page.png"})},
+		{"wrong viewport", epub(file{"OEBPS/Text/page.xhtml", `<html><head><meta name="viewport" content="width=20,height=20"/></head><body/></html>`, zip.Deflate})},
+		{"wrong size", epub(file{"OEBPS/Text/page.xhtml", `<html><head><meta name="viewport" content="width=10,height=20"/></head><body><img src="../Images/page.png" style="width:5px; height:10px"/></body></html>`, zip.Deflate})},
+	} {
+		path := filepath.Join(dir, "book.epub")
+		write(path, tc.files)
+		r := Verify(path)
+		fmt.Println(tc.name, r.Valid, r.Errors)
+	}
+
+	// Output:
+	// valid true []
+	// compressed mimetype false [mimetype is compressed]
+	// wrong mimetype false [mimetype should be application/epub+zip]
+	// no mimetype false [mimetype is not the first file]
+	// no container false [META-INF/container.xml is missing]
+	// no rootfile false [META-INF/container.xml: rootfile is missing]
+	// no package false [package document "OEBPS/content.opf" is missing]
+	// no image false [manifest: OEBPS/Images/page.png is missing]
+	// wrong viewport false [OEBPS/Text/page.xhtml: viewport "width=20,height=20", expected "width=10,height=20"]
+	// wrong size false [OEBPS/Text/page.xhtml: image OEBPS/Images/page.png (10x20) displayed at 5x10, expected 10x20]
+}
@@ -2,50 +2,70 @@
 Package epubzip Helper to write EPUB files.
 
 We create a zip with the magic EPUB mimetype.
+The EPUB is written to a temporary file next to it, and renamed once complete.
 */
 package epubzip
 
 import (
 	"archive/zip"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
 )
 
 type EPUBZip struct {
-	w  *os.File
-	wz *zip.Writer
+	path    string
+	modTime time.Time
+	w       *os.File
+	wz      *zip.Writer
 }
 
-// New create a new EPUB
-func New(path string) (EPUBZip, error) {
-	w, err := os.Create(path)
+// TempSuffix suffix of the temporary files, a leftover is the sign of a crashed run.
+const TempSuffix = ".tmp"
+
+// New create a new EPUB, all the files have the modification time.
+func New(path string, modTime time.Time) (EPUBZip, error) {
+	w, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*"+TempSuffix)
 	if err != nil {
 		return EPUBZip{}, err
 	}
 	wz := zip.NewWriter(w)
-	return EPUBZip{w, wz}, nil
+	return EPUBZip{path, modTime.UTC(), w, wz}, nil
 }
 
-// Close compress pipe and file.
+// Close compress pipe and file, then move the EPUB to its final path.
 func (e EPUBZip) Close() error {
 	if err := e.wz.Close(); err != nil {
 		return err
 	}
-	return e.w.Close()
+	if err := e.w.Sync(); err != nil {
+		return err
+	}
+	if err := e.w.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(e.w.Name(), 0644); err != nil {
+		return err
+	}
+	return utils.Rename(e.w.Name(), e.path)
+}
+
+// Abort remove the temporary file if the EPUB hasn't been closed.
+func (e EPUBZip) Abort() {
+	_ = e.w.Close()
+	_ = os.Remove(e.w.Name())
 }
 
 // WriteMagic Write mimetype, in a very specific way.
 //
 // This will be valid with epubcheck tools.
 func (e EPUBZip) WriteMagic() error {
-	t := time.Now().UTC()
-	//goland:noinspection GoDeprecation
 	fh := zip.FileHeader{
 		Name:               "mimetype",
 		Method:             zip.Store,
-		Modified:           t,
-		ModifiedTime:       uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11),
-		ModifiedDate:       uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9),
 		CompressedSize64:   20,
 		UncompressedSize64: 20,
 		CRC32:              0x2cab616f,
@@ -53,6 +73,7 @@ func (e EPUBZip) WriteMagic() error {
 	fh.CreatorVersion = fh.CreatorVersion&0xff00 | 20 // preserve compatibility byte
 	fh.ReaderVersion = 20
 	fh.SetMode(0600)
+	e.setModified(&fh)
 	m, err := e.wz.CreateRaw(&fh)
 
 	if err != nil {
@@ -62,13 +83,27 @@ func (e EPUBZip) WriteMagic() error {
 	return err
 }
 
+// Copy a file from another zip without decompressing it.
 func (e EPUBZip) Copy(fz *zip.File) error {
-	return e.wz.Copy(fz)
+	r, err := fz.OpenRaw()
+	if err != nil {
+		return err
+	}
+	fh := fz.FileHeader
+	e.setModified(&fh)
+	m, err := e.wz.CreateRaw(&fh)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(m, r)
+	return err
 }
 
 // WriteRaw Write image. They are already compressed, so we write them down directly.
 func (e EPUBZip) WriteRaw(raw Image) error {
-	m, err := e.wz.CreateRaw(raw.Header)
+	fh := *raw.Header
+	e.setModified(&fh)
+	m, err := e.wz.CreateRaw(&fh)
 	if err != nil {
 		return err
 	}
@@ -80,7 +115,7 @@ func (e EPUBZip) WriteRaw(raw Image) error {
 func (e EPUBZip) WriteContent(file string, content []byte) error {
 	m, err := e.wz.CreateHeader(&zip.FileHeader{
 		Name:     file,
-		Modified: time.Now(),
+		Modified: e.modTime,
 		Method:   zip.Deflate,
 	})
 	if err != nil {
@@ -89,3 +124,12 @@ func (e EPUBZip) WriteContent(file string, content []byte) error {
 	_, err = m.Write(content)
 	return err
 }
+
+// set the modification time of a raw file, the zip writer only does it for the compressed files.
+func (e EPUBZip) setModified(fh *zip.FileHeader) {
+	t := e.modTime
+	//goland:noinspection GoDeprecation
+	fh.ModifiedTime = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	//goland:noinspection GoDeprecation
+	fh.ModifiedDate = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+}
@@ -17,6 +17,11 @@ type Image struct {
 	Data   []byte
 }
 
+// Size of the image into the zip: local header, name and compressed data.
+func (i Image) Size() uint64 {
+	return uint64(len(i.Data)) + 30 + uint64(len(i.Header.Name))
+}
+
 // CompressImage create gzip encoded jpeg
 func CompressImage(filename string, format string, img image.Image, quality int) (Image, error) {
 	var (
@@ -3,6 +3,8 @@ package utils
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 )
 
@@ -54,3 +56,24 @@ func NumberOfDigits(i int) int {
 func FormatNumberOfDigits(i int) string {
 	return "%0" + IntToString(NumberOfDigits(i)) + "d"
 }
+
+// Rename move the file, then sync its directory so the rename survives a crash.
+//
+// Windows can't sync a directory, the rename is already durable there.
+func Rename(oldPath, newPath string) error {
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(filepath.Dir(newPath))
+	if err != nil {
+		return err
+	}
+	if err = d.Sync(); err != nil {
+		_ = d.Close()
+		return err
+	}
+	return d.Close()
+}
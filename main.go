@@ -8,13 +8,22 @@ EPUB is now support by Amazon through [SendToKindle](https://www.amazon.com/gp/s
 package main
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 
 	"github.com/tcnksm/go-latest"
 
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/converter"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubdevice"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubevent"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubinspect"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubledger"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubopds"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubverify"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
 	"github.com/celogeek/go-comic-converter/v3/pkg/epub"
 )
@@ -26,6 +35,35 @@ func main() {
 	}
 	cmd.InitParse()
 	cmd.Parse()
+	if err := epubevent.Open(cmd.Options.Json, cmd.Options.Events); err != nil {
+		cmd.Fatal(err)
+	}
+	defer func() {
+		_ = epubevent.Close()
+	}()
+
+	if cmd.Command != nil {
+		switch cmd.Command.Name {
+		case "convert":
+			generate(cmd)
+		case "inspect":
+			inspect(cmd)
+		case "profiles":
+			profiles(cmd)
+		case "config":
+			config(cmd)
+		case "verify":
+			verify(cmd)
+		case "serve":
+			cmd.Options.OpdsServe = cmd.Arg(0)
+			opds(cmd)
+		case "completion":
+			completion(cmd)
+		case "version":
+			version()
+		}
+		return
+	}
 
 	switch {
 	case cmd.Options.Version:
@@ -36,6 +74,10 @@ func main() {
 		show(cmd)
 	case cmd.Options.Reset:
 		reset(cmd)
+	case cmd.Options.OpdsServe != "":
+		opds(cmd)
+	case cmd.Options.Inspect:
+		inspect(cmd)
 	default:
 		generate(cmd)
 	}
@@ -83,52 +125,337 @@ func save(cmd *converter.Converter) {
 	if err := cmd.Options.SaveConfig(); err != nil {
 		cmd.Fatal(err)
 	}
+	target := cmd.Options.FileName()
+	if cmd.Options.Preset != "" {
+		target = "preset " + cmd.Options.Preset + " of " + target
+	}
 	utils.Printf(
 		"%s%s\n\nSaving to %s\n",
 		cmd.Options.Header(),
 		cmd.Options.ShowConfig(),
-		cmd.Options.FileName(),
+		target,
 	)
 }
 
 func show(cmd *converter.Converter) {
-	utils.Println(cmd.Options.Header(), cmd.Options.ShowConfig())
+	utils.Println(cmd.Options.Header(), cmd.Options.ShowEffectiveConfig())
 }
 
 func reset(cmd *converter.Converter) {
 	if err := cmd.Options.ResetConfig(); err != nil {
 		cmd.Fatal(err)
 	}
+	target := "default"
+	if cmd.Options.Preset != "" {
+		target = "preset " + cmd.Options.Preset
+	}
 	utils.Printf(
-		"%s%s\n\nReset default to %s\n",
+		"%s%s\n\nReset %s to %s\n",
 		cmd.Options.Header(),
 		cmd.Options.ShowConfig(),
+		target,
 		cmd.Options.FileName(),
 	)
 }
 
+func profiles(cmd *converter.Converter) {
+	if cmd.Options.Json {
+		epubevent.Emit(epubevent.TypeProfiles, cmd.Options.Profiles())
+		return
+	}
+	utils.Printf("%-8s %-40s %5s %5s  %s\n", "CODE", "DESCRIPTION", "WIDTH", "HEIGHT", "CAPABILITIES")
+	for _, p := range cmd.Options.Profiles() {
+		utils.Printf("%-8s %-40s %5d %5d  %s\n", p.Code, p.Description, p.Width, p.Height, p.Capabilities())
+	}
+}
+
+func config(cmd *converter.Converter) {
+	args := cmd.Args()
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	if err := cmd.ConfigError(); err != nil && action != "validate" && action != "reset" {
+		cmd.Fatal(err)
+	}
+	switch {
+	case action == "validate" && len(args) == 1:
+		validateConfig(cmd)
+	case action == "show" && len(args) <= 1:
+		show(cmd)
+	case action == "save" && len(args) == 1:
+		save(cmd)
+	case action == "reset" && len(args) == 1:
+		reset(cmd)
+	case action == "get" && len(args) == 1:
+		for _, k := range cmd.Options.Keys() {
+			v, _ := cmd.Options.Get(k)
+			utils.Printf("%s: %s\n", k, v)
+		}
+	case action == "get" && len(args) == 2:
+		v, err := cmd.Options.Get(args[1])
+		if err != nil {
+			cmd.Fatal(err)
+		}
+		utils.Println(v)
+	case action == "set" && len(args) == 3:
+		if err := cmd.Options.Set(args[1], args[2]); err != nil {
+			cmd.Fatal(err)
+		}
+		if p := cmd.Options.GetProfile(); p == nil {
+			cmd.Fatal(fmt.Errorf("profile %q doesn't exists", cmd.Options.Profile))
+		}
+		save(cmd)
+	default:
+		cmd.Fatal(fmt.Errorf("unknown config action: %s", strings.Join(args, " ")))
+	}
+}
+
+func validateConfig(cmd *converter.Converter) {
+	errs := cmd.ValidateConfigFile()
+	if cmd.Options.Json {
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		epubevent.Emit(epubevent.TypeConfig, map[string]any{
+			"file":   cmd.Options.FileName(),
+			"valid":  len(errs) == 0,
+			"errors": messages,
+		})
+	} else if len(errs) == 0 {
+		utils.Printf("%s: OK\n", cmd.Options.FileName())
+	} else {
+		utils.Printf("%s: %d errors\n", cmd.Options.FileName(), len(errs))
+		for _, err := range errs {
+			utils.Printf("  - %s\n", err)
+		}
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+func completion(cmd *converter.Converter) {
+	script, err := converter.Completion(cmd.Arg(0))
+	if err != nil {
+		cmd.Fatal(err)
+	}
+	_, _ = os.Stdout.WriteString(script)
+}
+
+func verify(cmd *converter.Converter) {
+	files := cmd.Args()
+	if len(files) == 0 {
+		cmd.Fatal(errors.New("missing EPUB to verify"))
+	}
+
+	valid := true
+	reports := make([]epubverify.Report, 0, len(files))
+	for _, f := range files {
+		r := epubverify.Verify(f)
+		valid = valid && r.Valid
+		reports = append(reports, r)
+	}
+
+	if cmd.Options.Json {
+		epubevent.Emit(epubevent.TypeVerify, reports)
+	} else {
+		for _, r := range reports {
+			utils.Println(r)
+		}
+	}
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+func opds(cmd *converter.Converter) {
+	fi, err := os.Stat(cmd.Options.OpdsServe)
+	if err != nil {
+		cmd.Fatal(err)
+	}
+	if !fi.IsDir() {
+		cmd.Fatal(errors.New("opds-serve should be a directory"))
+	}
+	utils.Printf("Serving OPDS catalog of %s on %s\n", cmd.Options.OpdsServe, cmd.Options.OpdsAddr)
+	if err = epubopds.New(cmd.Options.OpdsServe).ListenAndServe(cmd.Options.OpdsAddr); err != nil {
+		utils.Fatalf("Error: %v\n", err)
+	}
+}
+
+func inspect(cmd *converter.Converter) {
+	if cmd.Options.Input == "" {
+		cmd.Fatal(errors.New("missing input"))
+	}
+
+	report, err := epubinspect.New(cmd.Options.EPUBOptions)
+	if err != nil {
+		utils.Fatalf("Error: %v\n", err)
+	}
+
+	if cmd.Options.Json {
+		epubevent.Emit(epubevent.TypeInspect, report)
+	} else {
+		utils.Println(report)
+	}
+}
+
 func generate(cmd *converter.Converter) {
+	// a v2 config file is rewritten, the backup is reported as a config event in json
+	if backup, err := cmd.Options.MigrateConfig(); err != nil {
+		warn(cmd, fmt.Sprintf("cannot migrate the config: %v", err), cmd.Options.FileName())
+	} else if backup != "" {
+		epubevent.Emit(epubevent.TypeConfig, map[string]any{
+			"file":     cmd.Options.FileName(),
+			"migrated": true,
+			"from":     2,
+			"to":       converter.ConfigVersion,
+			"backup":   backup,
+		})
+		if !cmd.Options.Json {
+			utils.Printf("Config %s migrated from v2 to v%d, backup saved to %s\n", cmd.Options.FileName(), converter.ConfigVersion, backup)
+		}
+	}
+
+	var device epubdevice.Device
+	if cmd.Options.DeliverToDevice {
+		devices := epubdevice.Detect()
+		if len(devices) == 0 {
+			failed(cmd, errors.New("no e-reader found"))
+		}
+		if len(devices) > 1 {
+			names := make([]string, 0, len(devices))
+			for _, d := range devices {
+				names = append(names, d.String())
+			}
+			failed(cmd, fmt.Errorf("multiple e-readers found, keep only one plugged: %s", strings.Join(names, ", ")))
+		}
+		device = devices[0]
+		if !cmd.ProfileIsSet() {
+			if device.Profile == "" {
+				failed(cmd, fmt.Errorf("the model of %s is unknown, set its profile with -profile", device))
+			}
+			cmd.Options.Profile = device.Profile
+		}
+	}
+
 	if err := cmd.Validate(); err != nil {
-		cmd.Fatal(err)
+		failed(cmd, err)
 	}
 
 	if profile := cmd.Options.GetProfile(); profile != nil {
 		cmd.Options.Image.View.Width = profile.Width
 		cmd.Options.Image.View.Height = profile.Height
+		cmd.Options.Image.GrayLevels = profile.GrayLevels
+		// a color device keeps the colors, unless grayscale has been chosen
+		if profile.Color != nil && *profile.Color && !cmd.GrayScaleIsSet() {
+			cmd.Options.Image.GrayScale = false
+		}
 	}
 
-	if cmd.Options.Json {
-		_ = json.NewEncoder(os.Stdout).Encode(map[string]any{
-			"type": "options", "data": cmd.Options,
-		})
-	} else {
+	epubevent.Emit(epubevent.TypeOptions, cmd.Options)
+	if !cmd.Options.Json {
 		utils.Println(cmd.Options)
 	}
 
-	if err := epub.New(cmd.Options.EPUBOptions).Write(); err != nil {
+	// the ledger is only used for real conversions
+	var ledger *epubledger.Ledger
+	sources := []string{cmd.Options.Input}
+	if len(cmd.Options.Inputs) > 0 {
+		sources = make([]string, 0, len(cmd.Options.Inputs))
+		for _, source := range cmd.Options.Inputs {
+			sources = append(sources, source.Path)
+		}
+	}
+	optionsHash := cmd.Options.OptionsHash()
+	if !cmd.Options.Dry && !cmd.Options.Preview {
+		var err error
+		if ledger, err = epubledger.Load(filepath.Dir(cmd.Options.Output)); err != nil {
+			failed(cmd, err)
+		}
+		if !cmd.Options.Force && ledger.UpToDate(cmd.Options.Input, sources, optionsHash) {
+			parts := ledger.Parts(cmd.Options.Input)
+			epubevent.Emit(epubevent.TypeSkipped, map[string]any{
+				"input": cmd.Options.Input,
+				"parts": parts,
+			})
+			if !cmd.Options.Json {
+				utils.Printf("Skipping %s: up to date, use -force to convert again\n", cmd.Options.Input)
+				for _, f := range parts {
+					utils.Printf("  - %s\n", f)
+				}
+			}
+			// the device may not have the previous conversion
+			if cmd.Options.DeliverToDevice {
+				deliver(cmd, device, parts)
+			}
+			epubevent.EmitResult(nil)
+			return
+		}
+	}
+
+	e := epub.New(cmd.Options.EPUBOptions)
+	if err := e.Write(); err != nil {
+		epubevent.EmitResult(err)
 		utils.Fatalf("Error: %v\n", err)
 	}
+	if ledger != nil {
+		removed, err := ledger.Record(cmd.Options.Input, sources, optionsHash, e.Files())
+		if err != nil {
+			warn(cmd, fmt.Sprintf("cannot update the ledger: %v", err), filepath.Dir(cmd.Options.Output))
+		}
+		for _, f := range removed {
+			warn(cmd, "stale part of the previous conversion removed", f)
+		}
+	}
+	if cmd.Options.DeliverToDevice && !cmd.Options.Dry {
+		deliver(cmd, device, e.Files())
+	}
 	if !cmd.Options.Dry {
 		cmd.Stats()
 	}
+	epubevent.EmitResult(nil)
+}
+
+// failed report the error in the result event before exiting
+func failed(cmd *converter.Converter, err error) {
+	epubevent.EmitResult(err)
+	cmd.Fatal(err)
+}
+
+// warn display a warning, or report it as an event in json
+func warn(cmd *converter.Converter, message string, path string) {
+	epubevent.EmitWarning(message, path)
+	if cmd.Options.Json {
+		return
+	}
+	if path != "" && !strings.Contains(message, path) {
+		message += ": " + path
+	}
+	utils.Printf("Warning: %s\n", message)
+}
+
+func deliver(cmd *converter.Converter, device epubdevice.Device, files []string) {
+	copied, err := device.Deliver(files, cmd.Options.Series)
+	if err != nil {
+		if !errors.Is(err, epubdevice.ErrNotEnoughSpace) {
+			epubevent.EmitResult(err)
+			utils.Fatalf("Error: %v\n", err)
+		}
+		warn(cmd, fmt.Sprintf("%s: %v", device, err), device.Root)
+		return
+	}
+
+	epubevent.Emit(epubevent.TypeDevice, map[string]any{
+		"family": device.Family,
+		"root":   device.Root,
+		"files":  copied,
+	})
+	if !cmd.Options.Json {
+		utils.Printf("Copied to %s:\n", device)
+		for _, f := range copied {
+			utils.Printf("  - %s\n", f)
+		}
+	}
 }
@@ -3,8 +3,11 @@ package epub
 
 import (
 	"archive/zip"
+	"errors"
 	"fmt"
+	"maps"
 	"math"
+	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -14,9 +17,12 @@ import (
 
 	"github.com/gofrs/uuid"
 
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubevent"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimage"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimagepassthrough"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimageprocessor"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubname"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubpreview"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubprogress"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubtemplates"
 	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubtree"
@@ -27,21 +33,27 @@ import (
 
 type EPUB interface {
 	Write() error
+	Files() []string
 }
 
 type epub struct {
 	epuboptions.EPUBOptions
 	UID       string
 	Publisher string
-	UpdatedAt string
+	UpdatedAt time.Time
 
 	templateProcessor *template.Template
 	imageProcessor    epubimageprocessor.EPUBImageProcessor
+	files             *[]string
 }
 
 type epubPart struct {
 	Cover  epubimage.EPUBImage
 	Images []epubimage.EPUBImage
+	// Title of the part, the title of the EPUB with the part number if empty
+	Title string
+	// Index of the part in the series, the part number if 0
+	Index int
 }
 
 // New initialize EPUB
@@ -64,12 +76,18 @@ func New(options epuboptions.EPUBOptions) EPUB {
 		EPUBOptions:       options,
 		UID:               uid.String(),
 		Publisher:         "GO Comic Converter",
-		UpdatedAt:         time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:         time.Now().UTC(),
 		templateProcessor: tmpl,
 		imageProcessor:    imageProcessor,
+		files:             &[]string{},
 	}
 }
 
+// Files list of EPUB written by Write
+func (e epub) Files() []string {
+	return *e.files
+}
+
 // render templates
 func (e epub) render(templateString string, data map[string]any) string {
 	var result strings.Builder
@@ -155,7 +173,7 @@ func (e epub) writeCoverImage(wz epubzip.EPUBZip, img epubimage.EPUBImage, part,
 // write title image
 func (e epub) writeTitleImage(wz epubzip.EPUBZip, img epubimage.EPUBImage, title string) error {
 	titleAlign := ""
-	if !e.Image.View.PortraitOnly {
+	if !e.Image.SinglePageView() {
 		if e.Image.Manga {
 			titleAlign = "right:0"
 		} else {
@@ -163,7 +181,7 @@ func (e epub) writeTitleImage(wz epubzip.EPUBZip, img epubimage.EPUBImage, title
 		}
 	}
 
-	if !e.Image.View.PortraitOnly {
+	if !e.Image.SinglePageView() {
 		if err := wz.WriteContent(
 			"OEBPS/Text/space_title.xhtml",
 			[]byte(e.render(epubtemplates.Blank, map[string]any{
@@ -209,7 +227,9 @@ func (e epub) writeTitleImage(wz epubzip.EPUBZip, img epubimage.EPUBImage, title
 }
 
 // extract image and split it into part
-func (e epub) getParts() (parts []epubPart, imgStorage epubzip.StorageImageReader, err error) {
+//
+// In preview mode, the blank images removed are returned apart.
+func (e epub) getParts() (parts []epubPart, blanks []epubimage.EPUBImage, imgStorage epubzip.StorageImageReader, err error) {
 	images, err := e.imageProcessor.Load()
 
 	if err != nil {
@@ -224,57 +244,168 @@ func (e epub) getParts() (parts []epubPart, imgStorage epubzip.StorageImageReade
 		return images[i].Id < images[j].Id
 	})
 
+	// blank images are kept by the processor in preview mode
+	if e.Preview && e.Image.NoBlankImage {
+		kept := make([]epubimage.EPUBImage, 0, len(images))
+		for _, img := range images {
+			if img.IsBlank {
+				blanks = append(blanks, img)
+			} else {
+				kept = append(kept, img)
+			}
+		}
+		if len(kept) == 0 {
+			err = errors.New("no images found")
+			return
+		}
+		images = kept
+	}
+
 	parts = make([]epubPart, 0)
 	cover := images[0]
 	if e.Image.HasCover || (cover.DoublePage && !e.Image.KeepDoublePageIfSplit) {
 		images = images[1:]
 	}
 
-	if e.Dry {
+	if e.Image.DetectSpread {
+		e.markSpreads(images)
+	}
+
+	// dry run with sample use the estimated size of the images
+	imgSize := func(img epubimage.EPUBImage) uint64 {
+		return img.Size
+	}
+	if !e.Dry {
+		imgStorage, err = epubzip.NewStorageImageReader(e.ImgStorage())
+		if err != nil {
+			return
+		}
+		imgSize = func(img epubimage.EPUBImage) uint64 {
+			return imgStorage.Size(img.EPUBImgPath())
+		}
+	}
+
+	switch {
+	case e.SplitMode == 1:
+		parts = e.splitByDirectory(cover, images)
+	case e.SplitMode == 2:
+		parts = e.splitByPages(cover, images)
+	case e.Dry && e.DrySample <= 0:
 		parts = append(parts, epubPart{
 			Cover:  cover,
 			Images: images,
 		})
-		return
+	default:
+		parts = e.splitBySize(cover, images, imgSize, e.SplitMode == 3)
 	}
+	return
+}
 
-	imgStorage, err = epubzip.NewStorageImageReader(e.ImgStorage())
-	if err != nil {
-		return
+// size of a page into the EPUB, without the image
+const xhtmlSize = uint64(1024)
+
+// size of the EPUB part without the pages: descriptor files + title + cover
+func (e epub) baseSize(coverSize uint64) uint64 {
+	return uint64(128*1024) + coverSize*2
+}
+
+// estimated size of the part, the size of the images are computed during the dry run.
+func (e epub) estimatePartSize(part epubPart) uint64 {
+	size := e.baseSize(part.Cover.Size)
+	for _, img := range part.Images {
+		size += img.Size + xhtmlSize
 	}
+	return size
+}
 
-	// compute size of the EPUB part and try to be as close as possible of the target
-	maxSize := uint64(e.LimitMb * 1024 * 1024)
-	xhtmlSize := uint64(1024)
-	// descriptor files + title + cover
-	baseSize := uint64(128*1024) + imgStorage.Size(cover.EPUBImgPath())*2
+// display estimated size, parts and layout of the dry run
+//
+// The layout is exact, the size is approximate unless all pages are sampled.
+func (e epub) writeEstimate(epubParts []epubPart) {
+	totalParts := len(epubParts)
+	sample := min(e.DrySample, 100)
+	estimate := epubevent.Estimate{
+		Sample:      sample,
+		Approximate: sample < 100,
+		Parts:       make([]epubevent.EstimatePart, 0, totalParts),
+	}
+	for i, part := range epubParts {
+		first, last := part.Images[0], part.Images[len(part.Images)-1]
+		size := e.estimatePartSize(part)
+		estimate.Size += size
+		estimate.Parts = append(estimate.Parts, epubevent.EstimatePart{
+			Part:      i + 1,
+			Size:      size,
+			Pages:     len(part.Images),
+			FirstPage: filepath.Join(first.Path, first.Name),
+			LastPage:  filepath.Join(last.Path, last.Name),
+		})
+	}
+	epubevent.Emit(epubevent.TypeEstimate, estimate)
 
-	currentSize := baseSize
-	currentImages := make([]epubimage.EPUBImage, 0)
-	part := 1
+	approx := ""
+	if estimate.Approximate {
+		approx = "~"
+		utils.Printf("Estimate (sample %d%%, approximate size):\n", sample)
+	} else {
+		utils.Printf("Estimate (sample %d%%):\n", sample)
+	}
+	utils.Printf("    %-32s: %s%s Mb\n", "Size", approx, utils.FloatToString(float64(estimate.Size)/1024/1024, 2))
+	utils.Printf("    %-32s: %d\n", "Parts", totalParts)
+	for _, part := range estimate.Parts {
+		utils.Printf(
+			"  - Part %d: %s%s Mb, %d pages, %s -> %s\n",
+			part.Part,
+			approx,
+			utils.FloatToString(float64(part.Size)/1024/1024, 2),
+			part.Pages,
+			part.FirstPage,
+			part.LastPage,
+		)
+	}
 
-	for _, img := range images {
-		imgSize := imgStorage.Size(img.EPUBImgPath()) + xhtmlSize
-		if maxSize > 0 && len(currentImages) > 0 && currentSize+imgSize > maxSize {
-			parts = append(parts, epubPart{
-				Cover:  cover,
-				Images: currentImages,
-			})
-			part += 1
-			currentSize = baseSize
-			currentImages = make([]epubimage.EPUBImage, 0)
+	for i, part := range epubParts {
+		utils.Printf("\nLayout part %d:\n", i+1)
+		content := epubtemplates.Content{
+			HasTitlePage: e.TitlePage == 1 || (e.TitlePage == 2 && totalParts > 1),
+			ImageOptions: e.Image,
+			Images:       part.Images,
+		}
+		for _, l := range content.Layout() {
+			utils.Printf("  - %s\n", l)
 		}
-		currentSize += imgSize
-		currentImages = append(currentImages, img)
 	}
-	if len(currentImages) > 0 {
-		parts = append(parts, epubPart{
-			Cover:  cover,
-			Images: currentImages,
+	utils.Println()
+}
+
+// PreviewPath path of the HTML preview report
+func (e epub) previewPath() string {
+	ext := filepath.Ext(e.Output)
+	return e.Output[0:len(e.Output)-len(ext)] + ".preview.html"
+}
+
+// write the HTML preview of the processed pages
+func (e epub) writePreview(epubParts []epubPart, blanks []epubimage.EPUBImage) error {
+	totalParts := len(epubParts)
+	parts := make([]epubpreview.Part, 0, totalParts)
+	for _, part := range epubParts {
+		parts = append(parts, epubpreview.Part{
+			Cover: part.Cover,
+			Content: epubtemplates.Content{
+				HasTitlePage: e.TitlePage == 1 || (e.TitlePage == 2 && totalParts > 1),
+				ImageOptions: e.Image,
+				Images:       part.Images,
+			},
 		})
 	}
 
-	return
+	path := e.previewPath()
+	if err := epubpreview.Write(path, e.Title, parts, blanks); err != nil {
+		return err
+	}
+	*e.files = append(*e.files, path)
+	utils.Printf("Preview: %s\n", path)
+	return nil
 }
 
 // create a tree from the directories.
@@ -346,19 +477,33 @@ func (e epub) computeViewPort(epubParts []epubPart) (int, int) {
 
 func (e epub) writePart(path string, currentPart, totalParts int, part epubPart, imgStorage epubzip.StorageImageReader) error {
 	hasTitlePage := e.TitlePage == 1 || (e.TitlePage == 2 && totalParts > 1)
+	if e.Image.MergeSpread {
+		part.Images = e.mergeSpreads(part.Images, hasTitlePage)
+	}
 
-	wz, err := epubzip.New(path)
+	wz, err := epubzip.New(path, e.UpdatedAt)
 	if err != nil {
 		return err
 	}
-	defer func(wz epubzip.EPUBZip) {
-		_ = wz.Close()
-	}(wz)
+	defer wz.Abort()
 
 	title := e.Title
-	if totalParts > 1 {
+	if part.Title != "" {
+		title = part.Title
+	} else if totalParts > 1 {
 		title = title + " [" + utils.IntToString(currentPart) + "/" + utils.IntToString(totalParts) + "]"
 	}
+	// a single EPUB is indexed by its volume, the parts of a split by their number
+	index := currentPart
+	if part.Index > 0 {
+		index = part.Index
+	} else if totalParts == 1 {
+		index = e.Volume
+	}
+	series := e.Series
+	if series == "" {
+		series = e.Title
+	}
 
 	type zipContent struct {
 		Name    string
@@ -369,15 +514,16 @@ func (e epub) writePart(path string, currentPart, totalParts int, part epubPart,
 		{"META-INF/com.apple.ibooks.display-options.xml", epubtemplates.AppleBooks},
 		{"OEBPS/content.opf", epubtemplates.Content{
 			Title:        title,
+			Series:       series,
 			HasTitlePage: hasTitlePage,
 			UID:          e.UID,
 			Author:       e.Author,
 			Publisher:    e.Publisher,
-			UpdatedAt:    e.UpdatedAt,
+			UpdatedAt:    e.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 			ImageOptions: e.Image,
 			Cover:        part.Cover,
 			Images:       part.Images,
-			Current:      currentPart,
+			Current:      index,
 			Total:        totalParts,
 		}.String()},
 		{"OEBPS/toc.xhtml", epubtemplates.Toc(title, hasTitlePage, e.StripFirstDirectoryFromToc, part.Images)},
@@ -407,13 +553,17 @@ func (e epub) writePart(path string, currentPart, totalParts int, part epubPart,
 
 	lastImage := part.Images[len(part.Images)-1]
 	for _, img := range part.Images {
-		if err := e.writeImage(wz, img, imgStorage.Get(img.EPUBImgPath())); err != nil {
+		if img.Facing != nil {
+			if err := e.writeSpread(wz, img, imgStorage); err != nil {
+				return err
+			}
+		} else if err := e.writeImage(wz, img, imgStorage.Get(img.EPUBImgPath())); err != nil {
 			return err
 		}
 
 		// Double Page or Last Image that is not a double page
-		if !e.Image.View.PortraitOnly &&
-			(img.DoublePage ||
+		if !e.Image.SinglePageView() &&
+			(img.DoublePage || img.Spread ||
 				(!e.Image.KeepDoublePageIfSplit && img.Part == 1) ||
 				(img.Part == 0 && img == lastImage)) {
 			if err := e.writeBlank(wz, img); err != nil {
@@ -421,18 +571,59 @@ func (e epub) writePart(path string, currentPart, totalParts int, part epubPart,
 			}
 		}
 	}
-	return nil
+	return wz.Close()
+}
+
+// path of the EPUB part, from the output template if set
+func (e epub) partPath(currentPart, totalParts int, part epubPart) string {
+	if e.OutputTemplate == "" {
+		ext := filepath.Ext(e.Output)
+		suffix := ""
+		if totalParts > 1 {
+			fmtLen := utils.FormatNumberOfDigits(totalParts)
+			fmtPart := "Part " + fmtLen + " of " + fmtLen
+			suffix = fmt.Sprintf(fmtPart, currentPart, totalParts)
+		}
+		return e.Output[0:len(e.Output)-len(ext)] + suffix + ext
+	}
+
+	fields := maps.Clone(e.OutputFields)
+	fields["part"] = utils.IntToString(currentPart)
+	fields["parts"] = utils.IntToString(totalParts)
+	if part.Index > 0 {
+		fields["volume"] = utils.IntToString(part.Index)
+	} else if fields["volume"] == "" {
+		fields["volume"] = utils.IntToString(currentPart)
+	}
+	if part.Title != "" {
+		fields["title"] = part.Title
+	}
+
+	return filepath.Join(filepath.Dir(e.Output), epubname.Render(e.OutputTemplate, fields))
 }
 
 // create the zip
 func (e epub) Write() error {
-	epubParts, imgStorage, err := e.getParts()
+	if !e.Dry {
+		// fail before the conversion if possible
+		if _, err := os.Stat(e.Output); err == nil && e.OutputTemplate == "" && e.Overwrite == "never" {
+			return errExists(e.Output)
+		}
+		if err := e.removeLeftovers(); err != nil {
+			return err
+		}
+	}
+
+	epubParts, blanks, imgStorage, err := e.getParts()
 	if err != nil {
 		return err
 	}
 
 	if e.Dry {
-		p := epubParts[0]
+		p := epubPart{Cover: epubParts[0].Cover}
+		for _, part := range epubParts {
+			p.Images = append(p.Images, part.Images...)
+		}
 		utils.Printf("TOC:\n  - %s\n%s\n", e.Title, e.getTree(p.Images, true))
 		if e.DryVerbose {
 			if e.Image.HasCover {
@@ -440,6 +631,12 @@ func (e epub) Write() error {
 			}
 			utils.Printf("Files:\n%s\n", e.getTree(p.Images, false))
 		}
+		if e.DrySample > 0 {
+			e.writeEstimate(epubParts)
+		}
+		if e.Preview {
+			return e.writePreview(epubParts, blanks)
+		}
 		return nil
 	}
 	defer func() {
@@ -447,30 +644,59 @@ func (e epub) Write() error {
 		_ = imgStorage.Remove()
 	}()
 
+	if e.Reproducible {
+		if e.UpdatedAt, err = e.BuildTime(); err != nil {
+			return err
+		}
+		if e.UID, err = e.reproducibleUID(); err != nil {
+			return err
+		}
+	}
+
 	totalParts := len(epubParts)
+	paths := make([]string, 0, totalParts)
+	for i, part := range epubParts {
+		paths = append(paths, e.partPath(i+1, totalParts, part))
+	}
+	if err = e.checkPartPaths(paths); err != nil {
+		return err
+	}
+	if err = e.removePartLeftovers(paths); err != nil {
+		return err
+	}
+
+	totalJob := 2
+	if e.Image.GlobalCrop() {
+		totalJob = 3
+	}
 
 	bar := epubprogress.New(epubprogress.Options{
 		Max:         totalParts,
 		Description: "Writing Part",
-		CurrentJob:  2,
-		TotalJob:    2,
+		CurrentJob:  totalJob,
+		TotalJob:    totalJob,
 		Quiet:       e.Quiet,
 		Json:        e.Json,
 	})
 
 	e.Image.View.Width, e.Image.View.Height = e.computeViewPort(epubParts)
+	firstPage := 1
 	for i, part := range epubParts {
-		ext := filepath.Ext(e.Output)
-		suffix := ""
-		if totalParts > 1 {
-			fmtLen := utils.FormatNumberOfDigits(totalParts)
-			fmtPart := "Part " + fmtLen + " of " + fmtLen
-			suffix = fmt.Sprintf(fmtPart, i+1, totalParts)
+		path := paths[i]
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
 		}
 
-		path := e.Output[0:len(e.Output)-len(ext)] + suffix + ext
-
-		if err := e.writePart(
+		keep, err := e.keepExisting(path)
+		if err != nil {
+			return err
+		}
+		if keep {
+			epubevent.EmitWarning("existing EPUB is newer than the input, kept", path)
+			if !e.Json {
+				utils.Printf("Keep %s: newer than the input\n", path)
+			}
+		} else if err := e.writePart(
 			path,
 			i+1,
 			totalParts,
@@ -479,6 +705,21 @@ func (e epub) Write() error {
 		); err != nil {
 			return err
 		}
+		*e.files = append(*e.files, path)
+
+		var size int64
+		if fi, err := os.Stat(path); err == nil {
+			size = fi.Size()
+		}
+		epubevent.Emit(epubevent.TypePart, epubevent.Part{
+			Part:      i + 1,
+			Parts:     totalParts,
+			Path:      path,
+			Size:      size,
+			FirstPage: firstPage,
+			LastPage:  firstPage + len(part.Images) - 1,
+		})
+		firstPage += len(part.Images)
 
 		_ = bar.Add(1)
 	}
@@ -487,7 +728,10 @@ func (e epub) Write() error {
 		utils.Println()
 	}
 
-	// display corrupted images
+	// display corrupted images, they are reported by the image events in json
+	if e.Json {
+		return nil
+	}
 	hasError := false
 	for pId, part := range epubParts {
 		if pId == 0 && e.Image.HasCover && part.Cover.Error != nil {
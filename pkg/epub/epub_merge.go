@@ -0,0 +1,104 @@
+package epub
+
+import (
+	"image"
+	"slices"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimage"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubtemplates"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubzip"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
+)
+
+// merge the facing pages of the part, with the positions of the spine before the merge.
+//
+// The merged image is the first page in reading order, with the facing page.
+// The pages of different directories, and the pages next to a blank page, stay alone.
+func (e epub) mergeSpreads(images []epubimage.EPUBImage, hasTitlePage bool) []epubimage.EPUBImage {
+	facingOptions := e.Image
+	facingOptions.MergeSpread = false
+	spine := epubtemplates.Content{
+		HasTitlePage: hasTitlePage,
+		ImageOptions: facingOptions,
+		Images:       slices.Clone(images),
+	}.Spine()
+
+	index := map[string]int{}
+	for i, img := range images {
+		index[img.PageKey()] = i
+	}
+
+	first, second := "left", "right"
+	if e.Image.Manga {
+		first, second = "right", "left"
+	}
+
+	merged := make([]epubimage.EPUBImage, 0, len(images))
+	for i := 0; i < len(spine); i++ {
+		a, ok := index[spine[i].Idref]
+		if !ok {
+			continue
+		}
+		img := images[a]
+		if i+1 < len(spine) && spine[i].Position == first && spine[i+1].Position == second {
+			if b, ok := index[spine[i+1].Idref]; ok && images[b].Path == img.Path {
+				facing := images[b]
+				img.Facing = &facing
+				img.Width, img.Height = img.Width+facing.Width, max(img.Height, facing.Height)
+				i++
+			}
+		}
+		merged = append(merged, img)
+	}
+	return merged
+}
+
+// write the page and the image of a spread, composed from the stored pages
+func (e epub) writeSpread(wz epubzip.EPUBZip, img epubimage.EPUBImage, imgStorage epubzip.StorageImageReader) error {
+	left, right := img, *img.Facing
+	if e.Image.Manga {
+		left, right = right, left
+	}
+	leftImg, err := decodeStorage(imgStorage, left)
+	if err != nil {
+		return err
+	}
+	rightImg, err := decodeStorage(imgStorage, right)
+	if err != nil {
+		return err
+	}
+	data, err := e.imageProcessor.MergeSpreadData(img.EPUBImgPath(), leftImg, rightImg)
+	if err != nil {
+		return err
+	}
+
+	// only the merged pages are two pages wide
+	view := e.Image.View
+	view.Width *= 2
+	if err = wz.WriteContent(
+		img.EPUBPagePath(),
+		[]byte(e.render(epubtemplates.Text, map[string]any{
+			"Title":      "Image " + utils.IntToString(img.Id) + " and " + utils.IntToString(img.Facing.Id),
+			"ViewPort":   view.Port(),
+			"BodyStyle":  "width:" + utils.IntToString(view.Width) + "px",
+			"ImagePath":  img.ImgPath(),
+			"ImageStyle": img.ImgStyle(view.Width, view.Height, ""),
+		})),
+	); err != nil {
+		return err
+	}
+	return wz.WriteRaw(data)
+}
+
+// decode a page from the storage
+func decodeStorage(imgStorage epubzip.StorageImageReader, img epubimage.EPUBImage) (image.Image, error) {
+	f, err := imgStorage.Get(img.EPUBImgPath()).Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	src, _, err := image.Decode(f)
+	return src, err
+}
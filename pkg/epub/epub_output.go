@@ -0,0 +1,108 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubevent"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubzip"
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/utils"
+)
+
+func errExists(path string) error {
+	return fmt.Errorf("%s already exists, use -overwrite always or ifnewer", path)
+}
+
+// keepExisting check if an existing EPUB should be kept, following the overwrite policy.
+func (e epub) keepExisting(path string) (bool, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false, nil
+	}
+	switch e.Overwrite {
+	case "never":
+		return true, errExists(path)
+	case "ifnewer":
+		return !e.InputModTime().After(fi.ModTime()), nil
+	}
+	return false, nil
+}
+
+// checkPartPaths check the paths of all parts before writing anything.
+//
+// With the overwrite policy never, no part is written if one of them exists.
+func (e epub) checkPartPaths(paths []string) error {
+	seen := map[string]int{}
+	for i, path := range paths {
+		if j, ok := seen[path]; ok {
+			return fmt.Errorf("parts %d and %d are both written to %s, add {part} to the output template", j+1, i+1, path)
+		}
+		seen[path] = i
+		if _, err := os.Stat(path); err == nil && e.Overwrite == "never" {
+			return errExists(path)
+		}
+	}
+	return nil
+}
+
+// remove the temporary files left by a crashed run: the image storage and the EPUB not completed.
+//
+// The EPUB with the default names are found by pattern, whatever the number of parts of the crashed run.
+func (e epub) removeLeftovers() error {
+	prefix := strings.TrimSuffix(e.Output, filepath.Ext(e.Output))
+	partRegex := regexp.MustCompile("^" + regexp.QuoteMeta(filepath.Base(prefix)) + `( ?Part \d+ of \d+)?\.epub\.\d+` + regexp.QuoteMeta(epubzip.TempSuffix) + "$")
+
+	entries, err := os.ReadDir(filepath.Dir(e.Output))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(filepath.Dir(e.Output), entry.Name())
+		if entry.IsDir() || (path != e.ImgStorage() && (e.OutputTemplate != "" || !partRegex.MatchString(entry.Name()))) {
+			continue
+		}
+		if err = e.removeLeftover(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removePartLeftovers remove the EPUB not completed by a crashed run, from the rendered paths of the parts.
+func (e epub) removePartLeftovers(paths []string) error {
+	for _, path := range paths {
+		tmpRegex := regexp.MustCompile("^" + regexp.QuoteMeta(filepath.Base(path)) + `\.\d+` + regexp.QuoteMeta(epubzip.TempSuffix) + "$")
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !tmpRegex.MatchString(entry.Name()) {
+				continue
+			}
+			if err = e.removeLeftover(filepath.Join(filepath.Dir(path), entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e epub) removeLeftover(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	epubevent.EmitWarning("temporary file of a previous run removed", path)
+	if !e.Json {
+		utils.Printf("Removed temporary file of a previous run: %s\n", path)
+	}
+	return nil
+}
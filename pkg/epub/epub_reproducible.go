@@ -0,0 +1,67 @@
+package epub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubledger"
+	"github.com/celogeek/go-comic-converter/v3/pkg/epuboptions"
+)
+
+// UID derived from the content of the inputs and the options that change the EPUB.
+//
+// Only the options listed here are hashed, the location of the files and the run options don't change the content.
+func (e epub) reproducibleUID() (string, error) {
+	o := struct {
+		Author                     string            `json:"author"`
+		Title                      string            `json:"title"`
+		Series                     string            `json:"series"`
+		Volume                     int               `json:"volume"`
+		Sources                    []string          `json:"sources"`
+		TitlePage                  int               `json:"title_page"`
+		LimitMb                    int               `json:"limit_mb"`
+		SplitMode                  int               `json:"split_mode"`
+		SplitPages                 int               `json:"split_pages"`
+		StripFirstDirectoryFromToc bool              `json:"strip_first_directory"`
+		SortPathMode               int               `json:"sort_path_mode"`
+		Image                      epuboptions.Image `json:"image"`
+	}{
+		Author:                     e.Author,
+		Title:                      e.Title,
+		Series:                     e.Series,
+		Volume:                     e.Volume,
+		TitlePage:                  e.TitlePage,
+		LimitMb:                    e.LimitMb,
+		SplitMode:                  e.SplitMode,
+		SplitPages:                 e.SplitPages,
+		StripFirstDirectoryFromToc: e.StripFirstDirectoryFromToc,
+		SortPathMode:               e.SortPathMode,
+		Image:                      e.Image,
+	}
+	paths := []string{e.Input}
+	if len(e.Inputs) > 0 {
+		paths = paths[:0]
+		for _, source := range e.Inputs {
+			o.Sources = append(o.Sources, source.Title)
+			paths = append(paths, source.Path)
+		}
+	}
+
+	h := sha256.New()
+	b, err := json.Marshal(o)
+	if err != nil {
+		return "", err
+	}
+	_, _ = h.Write(b)
+	for _, path := range paths {
+		hash, err := epubledger.Hash(path)
+		if err != nil {
+			return "", err
+		}
+		_, _ = h.Write([]byte(hash))
+	}
+	return uuid.NewV5(uuid.NamespaceURL, "urn:go-comic-converter:"+hex.EncodeToString(h.Sum(nil))).String(), nil
+}
@@ -0,0 +1,148 @@
+package epub
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimage"
+)
+
+// split the images by size, as close as possible of the limit.
+//
+// With snap, the part ends at the last chapter that fit, a chapter bigger than the limit is still cut.
+func (e epub) splitBySize(cover epubimage.EPUBImage, images []epubimage.EPUBImage, imgSize func(epubimage.EPUBImage) uint64, snap bool) []epubPart {
+	parts := make([]epubPart, 0)
+	maxSize := uint64(e.LimitMb * 1024 * 1024)
+	baseSize := e.baseSize(imgSize(cover))
+
+	currentSize := baseSize
+	currentImages := make([]epubimage.EPUBImage, 0)
+	// start of the last chapter in the current images, and the size before it
+	chapterStart, chapterSize := 0, baseSize
+
+	for _, img := range images {
+		if n := len(currentImages); n > 0 && img.Path != currentImages[n-1].Path {
+			chapterStart, chapterSize = n, currentSize
+		}
+
+		size := imgSize(img) + xhtmlSize
+		for maxSize > 0 && len(currentImages) > 0 && currentSize+size > maxSize {
+			cut := len(currentImages)
+			if snap && chapterStart > 0 {
+				cut = chapterStart
+			}
+			parts = append(parts, epubPart{
+				Cover:  cover,
+				Images: currentImages[:cut],
+			})
+			currentImages = append([]epubimage.EPUBImage{}, currentImages[cut:]...)
+			if cut == chapterStart {
+				currentSize = baseSize + currentSize - chapterSize
+			} else {
+				currentSize = baseSize
+			}
+			chapterStart, chapterSize = 0, baseSize
+		}
+
+		currentSize += size
+		currentImages = append(currentImages, img)
+	}
+	if len(currentImages) > 0 {
+		parts = append(parts, epubPart{
+			Cover:  cover,
+			Images: currentImages,
+		})
+	}
+	return parts
+}
+
+// split the images every SplitPages pages, the parts of a double page stay together.
+func (e epub) splitByPages(cover epubimage.EPUBImage, images []epubimage.EPUBImage) []epubPart {
+	parts := make([]epubPart, 0)
+	currentImages := make([]epubimage.EPUBImage, 0)
+	for _, img := range images {
+		if n := len(currentImages); n >= e.SplitPages && img.Id != currentImages[n-1].Id {
+			parts = append(parts, epubPart{
+				Cover:  cover,
+				Images: currentImages,
+			})
+			currentImages = make([]epubimage.EPUBImage, 0)
+		}
+		currentImages = append(currentImages, img)
+	}
+	if len(currentImages) > 0 {
+		parts = append(parts, epubPart{
+			Cover:  cover,
+			Images: currentImages,
+		})
+	}
+	return parts
+}
+
+// check if all the images are inside the same directory at this depth
+func isSharedDir(dirs [][]string, depth int) bool {
+	for _, d := range dirs {
+		if len(d) <= depth+1 || d[depth] != dirs[0][depth] {
+			return false
+		}
+	}
+	return true
+}
+
+var volumeNumber = regexp.MustCompile(`\d+`)
+
+// split the images by top level directory, like the volumes of a big archive.
+//
+// The directories shared by all the images are skipped.
+// The number in the name of the directories is used as index of the series, if they are all different.
+func (e epub) splitByDirectory(cover epubimage.EPUBImage, images []epubimage.EPUBImage) []epubPart {
+	dirs := make([][]string, len(images))
+	for i, img := range images {
+		if p := filepath.Clean(img.Path); p != "." {
+			dirs[i] = strings.Split(p, string(filepath.Separator))
+		}
+	}
+
+	depth := 0
+	for isSharedDir(dirs, depth) {
+		depth++
+	}
+
+	parts := make([]epubPart, 0)
+	names := make([]string, 0)
+	for i, img := range images {
+		name := ""
+		if len(dirs[i]) > depth {
+			name = dirs[i][depth]
+		}
+		if n := len(parts); n > 0 && names[n-1] == name {
+			parts[n-1].Images = append(parts[n-1].Images, img)
+			continue
+		}
+		parts = append(parts, epubPart{
+			Cover:  cover,
+			Images: []epubimage.EPUBImage{img},
+		})
+		names = append(names, name)
+	}
+
+	indexes := make(map[int]bool)
+	for i, name := range names {
+		if name != "" {
+			parts[i].Title = e.Title + " - " + name
+		}
+		if n, err := strconv.Atoi(volumeNumber.FindString(name)); err == nil && !indexes[n] {
+			indexes[n] = true
+			parts[i].Index = n
+		}
+	}
+	// use the part number if some directories don't have a number
+	if len(indexes) != len(parts) {
+		for i := range parts {
+			parts[i].Index = 0
+		}
+	}
+	return parts
+}
@@ -0,0 +1,150 @@
+package epub
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimage"
+	"github.com/celogeek/go-comic-converter/v3/pkg/epuboptions"
+)
+
+// images of the chapters, with the number of pages and their size in Kb
+func chapters(chapters ...struct {
+	path        string
+	pages, size int
+}) (images []epubimage.EPUBImage) {
+	for _, c := range chapters {
+		for range c.pages {
+			images = append(images, epubimage.EPUBImage{Id: len(images), Path: filepath.FromSlash(c.path), Size: uint64(c.size * 1024)})
+		}
+	}
+	return
+}
+
+// ids of the pages of each part, with the title and the index if set
+func printParts(parts []epubPart) {
+	for _, part := range parts {
+		ids := make([]string, 0, len(part.Images))
+		for _, img := range part.Images {
+			id := fmt.Sprint(img.Id)
+			if img.Part > 0 {
+				id += fmt.Sprintf(".%d", img.Part)
+			}
+			ids = append(ids, id)
+		}
+		s := "[" + strings.Join(ids, " ") + "]"
+		if part.Title != "" {
+			s += fmt.Sprintf(" %q", part.Title)
+		}
+		if part.Index > 0 {
+			s += fmt.Sprintf(" #%d", part.Index)
+		}
+		fmt.Println(s)
+	}
+}
+
+func Example_splitBySize() {
+	type chapter = struct {
+		path        string
+		pages, size int
+	}
+	imgSize := func(img epubimage.EPUBImage) uint64 {
+		return img.Size
+	}
+	for _, tc := range []struct {
+		name    string
+		limitMb int
+		snap    bool
+		images  []epubimage.EPUBImage
+	}{
+		{"no limit", 0, false, chapters(chapter{"c1", 5, 300})},
+		{"limit", 1, false, chapters(chapter{"c1", 5, 300})},
+		{"page bigger than the limit", 1, false, chapters(chapter{"c1", 1, 100}, chapter{"c1", 1, 2000}, chapter{"c1", 1, 100})},
+		{"cut in a chapter", 1, false, chapters(chapter{"c1", 2, 200}, chapter{"c2", 3, 200})},
+		{"snap to the chapter", 1, true, chapters(chapter{"c1", 2, 200}, chapter{"c2", 3, 200})},
+		{"chapter bigger than the limit", 1, true, chapters(chapter{"c1", 6, 200})},
+	} {
+		e := epub{EPUBOptions: epuboptions.EPUBOptions{LimitMb: tc.limitMb}}
+		fmt.Println(tc.name)
+		printParts(e.splitBySize(epubimage.EPUBImage{}, tc.images, imgSize, tc.snap))
+	}
+	// Output: no limit
+	// [0 1 2 3 4]
+	// limit
+	// [0 1]
+	// [2 3]
+	// [4]
+	// page bigger than the limit
+	// [0]
+	// [1]
+	// [2]
+	// cut in a chapter
+	// [0 1 2 3]
+	// [4]
+	// snap to the chapter
+	// [0 1]
+	// [2 3 4]
+	// chapter bigger than the limit
+	// [0 1 2 3]
+	// [4 5]
+}
+
+func Example_splitByPages() {
+	images := []epubimage.EPUBImage{{Id: 0}, {Id: 1, Part: 1}, {Id: 1, Part: 2}, {Id: 2}, {Id: 3}, {Id: 4}}
+	for _, pages := range []int{1, 2, 4, 10} {
+		e := epub{EPUBOptions: epuboptions.EPUBOptions{SplitPages: pages}}
+		fmt.Println(pages, "pages")
+		printParts(e.splitByPages(epubimage.EPUBImage{}, images))
+	}
+	// Output: 1 pages
+	// [0]
+	// [1.1 1.2]
+	// [2]
+	// [3]
+	// [4]
+	// 2 pages
+	// [0 1.1 1.2]
+	// [2 3]
+	// [4]
+	// 4 pages
+	// [0 1.1 1.2 2]
+	// [3 4]
+	// 10 pages
+	// [0 1.1 1.2 2 3 4]
+}
+
+func Example_splitByDirectory() {
+	type chapter = struct {
+		path        string
+		pages, size int
+	}
+	for _, tc := range []struct {
+		name   string
+		images []epubimage.EPUBImage
+	}{
+		{"volumes", chapters(chapter{"Big/Vol 01/ch1", 1, 0}, chapter{"Big/Vol 01/ch2", 1, 0}, chapter{"Big/Vol 02/ch3", 2, 0})},
+		{"without number", chapters(chapter{"Vol 01", 1, 0}, chapter{"Extras", 1, 0})},
+		{"same number", chapters(chapter{"Part 1", 1, 0}, chapter{"Part 1 bis", 1, 0})},
+		{"pages at the top", chapters(chapter{"", 1, 0}, chapter{"Vol 03/ch1", 1, 0}, chapter{"Vol 03/ch2", 1, 0})},
+		{"single directory", chapters(chapter{"Big/Vol 01", 2, 0})},
+	} {
+		e := epub{EPUBOptions: epuboptions.EPUBOptions{Title: "Big"}}
+		fmt.Println(tc.name)
+		printParts(e.splitByDirectory(epubimage.EPUBImage{}, tc.images))
+	}
+	// Output: volumes
+	// [0 1] "Big - Vol 01" #1
+	// [2 3] "Big - Vol 02" #2
+	// without number
+	// [0] "Big - Vol 01"
+	// [1] "Big - Extras"
+	// same number
+	// [0] "Big - Part 1"
+	// [1] "Big - Part 1 bis"
+	// pages at the top
+	// [0]
+	// [1 2] "Big - Vol 03"
+	// single directory
+	// [0 1] "Big - Vol 01" #1
+}
@@ -0,0 +1,34 @@
+package epub
+
+import (
+	"math"
+
+	"github.com/celogeek/go-comic-converter/v3/internal/pkg/epubimage"
+)
+
+// mark the first page of the spreads scanned as two pages: the facing edges of consecutive pages continue the art.
+//
+// The first page is on the left, or on the right in manga mode.
+func (e epub) markSpreads(images []epubimage.EPUBImage) {
+	for i := 0; i+1 < len(images); i++ {
+		first, second := images[i], images[i+1]
+		if !isSinglePage(first) || !isSinglePage(second) || second.Id != first.Id+1 ||
+			math.Abs(first.OriginalAspectRatio-second.OriginalAspectRatio) > 0.05*first.OriginalAspectRatio {
+			continue
+		}
+		edge, facing := first.Edges[1], second.Edges[0]
+		if e.Image.Manga {
+			edge, facing = first.Edges[0], second.Edges[1]
+		}
+		if edge.Continue(facing) {
+			images[i].Spread = true
+			// a page is part of one spread only
+			i++
+		}
+	}
+}
+
+// page that is not part of a double page
+func isSinglePage(img epubimage.EPUBImage) bool {
+	return img.Part == 0 && !img.DoublePage && !img.IsBlank && img.Error == nil
+}
@@ -8,4 +8,18 @@ type Crop struct {
 	Bottom             int  `yaml:"bottom" json:"bottom"`
 	Limit              int  `yaml:"limit" json:"limit"`
 	SkipIfLimitReached bool `yaml:"skip_if_limit_reached" json:"skip_if_limit_reached"`
+	// Color of the margins in hexadecimal RGB, or auto to detect it on each edge
+	Color string `yaml:"color" json:"color"`
+	// Tolerance maximum difference of luminance with the color of the margins
+	Tolerance int `yaml:"tolerance" json:"tolerance"`
+	// SpeckArea maximum area of a mark ignored by the crop, per thousand of the page. 0 = disabled
+	SpeckArea int `yaml:"speck_area" json:"speck_area"`
+	// SpeckDistance minimum distance of a mark from the content, percentage of the page
+	SpeckDistance int `yaml:"speck_distance" json:"speck_distance"`
+	// RemoveSpecks erase the marks ignored by the crop
+	RemoveSpecks bool `yaml:"remove_specks" json:"remove_specks"`
+	// Mode 0 = each page, 1 = common to all pages, 2 = common to the left pages and to the right pages of the spine
+	Mode int `yaml:"mode" json:"mode"`
+	// Percentile of the margins of the pages used for the common crop, 50 is the median
+	Percentile int `yaml:"percentile" json:"percentile"`
 }
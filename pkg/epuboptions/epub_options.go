@@ -1,26 +1,55 @@
 // Package epuboptions for EPUB creation.
 package epuboptions
 
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
 type EPUBOptions struct {
 	// Output
 	Input  string `yaml:"-" json:"input"`
 	Output string `yaml:"-" json:"output"`
 	Author string `yaml:"-" json:"author"`
 	Title  string `yaml:"-" json:"title"`
+	// Series of the EPUB, from the ComicInfo.xml or the title, the title if empty
+	Series string `yaml:"-" json:"series"`
+	// Volume of the EPUB in the series, 0 if unknown
+	Volume int `yaml:"-" json:"volume,omitempty"`
+	// Inputs merged into an omnibus, set when -input is repeated
+	Inputs []Source `yaml:"-" json:"inputs,omitempty"`
 
 	//Config
 	TitlePage                  int   `yaml:"title_page" json:"title_page"`
 	LimitMb                    int   `yaml:"limit_mb" json:"limit_mb"`
+	SplitMode                  int   `yaml:"split_mode" json:"split_mode"`
+	SplitPages                 int   `yaml:"split_pages" json:"split_pages"`
 	StripFirstDirectoryFromToc bool  `yaml:"strip_first_directory" json:"strip_first_directory"`
 	SortPathMode               int   `yaml:"sort_path_mode" json:"sort_path_mode"`
 	Image                      Image `yaml:"image" json:"image"`
 
+	// OutputTemplate name of the EPUB relative to the output directory, see epubname
+	OutputTemplate string `yaml:"output_template" json:"output_template"`
+	// OutputFields values of the template, except the part fields
+	OutputFields map[string]string `yaml:"-" json:"-"`
+	// Overwrite policy of existing EPUB: always, never or ifnewer
+	Overwrite string `yaml:"overwrite" json:"overwrite"`
+	// Reproducible build: same input and options give the same EPUB
+	Reproducible bool `yaml:"reproducible" json:"reproducible"`
+
 	// Other
-	Dry        bool `yaml:"-" json:"dry"`
-	DryVerbose bool `yaml:"-" json:"dry_verbose"`
-	Quiet      bool `yaml:"-" json:"-"`
-	Json       bool `yaml:"-" json:"-"`
-	Workers    int  `yaml:"-" json:"workers"`
+	Dry        bool   `yaml:"-" json:"dry"`
+	DryVerbose bool   `yaml:"-" json:"dry_verbose"`
+	DrySample  int    `yaml:"-" json:"dry_sample"`
+	Preview    bool   `yaml:"-" json:"preview"`
+	Quiet      bool   `yaml:"-" json:"-"`
+	Json       bool   `yaml:"-" json:"-"`
+	Events     string `yaml:"-" json:"-"`
+	Workers    int    `yaml:"-" json:"workers"`
 }
 
 func (o EPUBOptions) WorkersRatio(pct int) (nbWorkers int) {
@@ -34,3 +63,43 @@ func (o EPUBOptions) WorkersRatio(pct int) (nbWorkers int) {
 func (o EPUBOptions) ImgStorage() string {
 	return o.Output + ".tmp"
 }
+
+// InputModTime last modification of the inputs, including the files of the directories
+func (o EPUBOptions) InputModTime() (modTime time.Time) {
+	inputs := []string{o.Input}
+	if len(o.Inputs) > 0 {
+		inputs = inputs[:0]
+		for _, source := range o.Inputs {
+			inputs = append(inputs, source.Path)
+		}
+	}
+	for _, input := range inputs {
+		_ = filepath.WalkDir(input, func(_ string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi, err := d.Info(); err == nil && fi.ModTime().After(modTime) {
+				modTime = fi.ModTime()
+			}
+			return nil
+		})
+	}
+	return
+}
+
+// BuildTime date of the EPUB.
+//
+// For a reproducible build, it is SOURCE_DATE_EPOCH if set, or the last modification of the inputs.
+func (o EPUBOptions) BuildTime() (time.Time, error) {
+	if !o.Reproducible {
+		return time.Now(), nil
+	}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		sec, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q", epoch)
+		}
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return o.InputModTime().UTC().Truncate(time.Second), nil
+}
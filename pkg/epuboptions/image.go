@@ -10,13 +10,29 @@ type Image struct {
 	AutoSplitDoublePage       bool   `yaml:"auto_split_double_page" json:"auto_split_double_page"`
 	KeepDoublePageIfSplit     bool   `yaml:"keep_double_page_if_split" json:"keep_double_page_if_split"`
 	KeepSplitDoublePageAspect bool   `yaml:"keep_split_double_page_aspect" json:"keep_split_double_page_aspect"`
+	SplitDoublePageGutter     bool   `yaml:"split_double_page_gutter" json:"split_double_page_gutter"`
+	SplitDoublePageOverlap    int    `yaml:"split_double_page_overlap" json:"split_double_page_overlap"`
+	SplitDoublePageTrimGutter bool   `yaml:"split_double_page_trim_gutter" json:"split_double_page_trim_gutter"`
+	DetectSpread              bool   `yaml:"detect_spread" json:"detect_spread"`
+	MergeSpread               bool   `yaml:"merge_spread" json:"merge_spread"`
 	NoBlankImage              bool   `yaml:"no_blank_image" json:"no_blank_image"`
 	Manga                     bool   `yaml:"manga" json:"manga"`
 	HasCover                  bool   `yaml:"has_cover" json:"has_cover"`
 	View                      View   `yaml:"view" json:"view"`
 	GrayScale                 bool   `yaml:"grayscale" json:"grayscale"`
 	GrayScaleMode             int    `yaml:"grayscale_mode" json:"gray_scale_mode"` // 0 = normal, 1 = average, 2 = luminance
+	GrayLevels                int    `yaml:"-" json:"gray_levels,omitempty"`        // levels of gray of the device, 0 = all
 	Resize                    bool   `yaml:"resize" json:"resize"`
 	Format                    string `yaml:"format" json:"format"`
 	AppleBookCompatibility    bool   `yaml:"apple_book_compatibility" json:"apple_book_compatibility"`
 }
+
+// SinglePageView check if the pages are shown one at a time: portrait only, or the facing pages merged into one image
+func (i Image) SinglePageView() bool {
+	return i.View.PortraitOnly || i.MergeSpread
+}
+
+// GlobalCrop check if the pages share a common crop, computed before the conversion
+func (i Image) GlobalCrop() bool {
+	return i.Format != "copy" && i.Crop.Enabled && i.Crop.Mode > 0
+}
@@ -0,0 +1,7 @@
+package epuboptions
+
+// Source of an omnibus, each source is a top level chapter of the TOC.
+type Source struct {
+	Path  string `yaml:"-" json:"path"`
+	Title string `yaml:"-" json:"title"`
+}